@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+// sdkClient is an AWS SDK service client that the resources can use.
+type sdkClient struct {
+	prefix string       // The IAM action prefix of the service.
+	client reflect.Type // The client type, its XRequest methods are the actions.
+}
+
+// sdkClients contains the AWS SDK service clients keyed by import path.
+var sdkClients = map[string]sdkClient{
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation":          {prefix: "cloudformation", client: reflect.TypeOf(&cloudformation.CloudFormation{})},
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront":              {prefix: "cloudfront", client: reflect.TypeOf(&cloudfront.CloudFront{})},
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity":         {prefix: "cognito-identity", client: reflect.TypeOf(&cognitoidentity.CognitoIdentity{})},
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider": {prefix: "cognito-idp", client: reflect.TypeOf(&cognitoidentityprovider.CognitoIdentityProvider{})},
+	"github.com/aws/aws-sdk-go-v2/service/iam":                     {prefix: "iam", client: reflect.TypeOf(&iam.IAM{})},
+	"github.com/aws/aws-sdk-go-v2/service/route53":                 {prefix: "route53", client: reflect.TypeOf(&route53.Route53{})},
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager":          {prefix: "secretsmanager", client: reflect.TypeOf(&secretsmanager.SecretsManager{})},
+	"github.com/aws/aws-sdk-go-v2/service/sts":                     {prefix: "sts", client: reflect.TypeOf(&sts.STS{})},
+}
+
+// findActions takes dir and returns the IAM actions of all AWS SDK requests that can be
+// made from the Handler function of the package in dir. Functions are followed by name,
+// so the result might contain more actions than are actually used but never fewer.
+// Returns []string and error.
+func findActions(dir string) ([]string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse %s. Error %s", dir, err.Error())
+	}
+
+	funcs, clients := map[string][]*ast.FuncDecl{}, []sdkClient{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, imp := range file.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				if c, ok := sdkClients[path]; ok {
+					clients = append(clients, c)
+				}
+			}
+
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+					funcs[fn.Name.Name] = append(funcs[fn.Name.Name], fn)
+				}
+			}
+		}
+	}
+
+	if _, ok := funcs["Handler"]; !ok {
+		return nil, fmt.Errorf("Couldn't find the Handler function in %s", dir)
+	}
+
+	actions, visited, queue := map[string]bool{}, map[string]bool{"Handler": true}, []string{"Handler"}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, fn := range funcs[name] {
+			var inspectErr error
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+
+				called := ""
+				switch fun := call.Fun.(type) {
+				case *ast.Ident:
+					called = fun.Name
+
+				case *ast.SelectorExpr:
+					called = fun.Sel.Name
+				}
+
+				if _, ok := funcs[called]; ok && !visited[called] {
+					visited[called] = true
+					queue = append(queue, called)
+				}
+
+				action, err := requestAction(called, clients)
+				switch {
+				case err != nil && inspectErr == nil:
+					inspectErr = fmt.Errorf("%s: %s", fset.Position(call.Pos()), err.Error())
+
+				case action != "":
+					actions[action] = true
+				}
+				return true
+			})
+
+			if inspectErr != nil {
+				return nil, inspectErr
+			}
+		}
+	}
+
+	l := []string{}
+	for action := range actions {
+		l = append(l, action)
+	}
+	sort.Strings(l)
+	return l, nil
+}
+
+// requestAction takes the name of a called method and returns the IAM action
+// of it if it's a request method on any of clients.
+// Returns string and error.
+func requestAction(method string, clients []sdkClient) (string, error) {
+	if !strings.HasSuffix(method, "Request") {
+		return "", nil
+	}
+
+	found := []string{}
+	for _, c := range clients {
+		if _, ok := c.client.MethodByName(method); ok {
+			action := c.prefix + ":" + strings.TrimSuffix(method, "Request")
+			if !contains(found, action) {
+				found = append(found, action)
+			}
+		}
+	}
+
+	if len(found) > 1 {
+		return "", fmt.Errorf("%s is ambiguous, it can be any of %s", method, strings.Join(found, ", "))
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+// checkActions takes res and the actions found in its code and returns an error if
+// the policy of res is missing any of them or contains actions that are never used.
+// Returns error.
+func checkActions(res *registry.Resource, found []string) error {
+	declared, implicit := map[string]bool{}, map[string]bool{}
+	for _, s := range res.Spec.Policy {
+		for _, action := range s.Actions {
+			declared[action] = true
+			implicit[action] = implicit[action] || s.Implicit
+		}
+	}
+
+	problems := []string{}
+	for _, action := range found {
+		if !declared[action] {
+			problems = append(problems, fmt.Sprintf("%s is used but missing from the policy", action))
+		}
+	}
+
+	for _, action := range res.Spec.Actions() {
+		if !implicit[action] && !contains(found, action) {
+			problems = append(problems, fmt.Sprintf("%s is in the policy but never used, mark the statement as Implicit if it's needed anyway", action))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s", filepath.ToSlash(res.Function), strings.Join(problems, ". "))
+	}
+	return nil
+}
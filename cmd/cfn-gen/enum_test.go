@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	userpoolclient "github.com/dwtechnologies/custom-cf/cognito/userpool-client"
+	userpoolfederation "github.com/dwtechnologies/custom-cf/cognito/userpool-federation"
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+// Test that the enum of the properties that are sent to Cognito as is allows every value
+// that the SDK has a constant for. The SDK is older than some values, such as the ALLOW_
+// auth flows, so those are listed as strings.
+func TestSDKEnums(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		property     string
+		values       []string
+	}{
+		{
+			resourceType: userpoolclient.ResourceType,
+			property:     "ExplicitAuthFlows",
+			values: []string{
+				string(cognitoidentityprovider.ExplicitAuthFlowsTypeAdminNoSrpAuth),
+				string(cognitoidentityprovider.ExplicitAuthFlowsTypeCustomAuthFlowOnly),
+				string(cognitoidentityprovider.ExplicitAuthFlowsTypeUserPasswordAuth),
+				"ALLOW_USER_SRP_AUTH",
+				"ALLOW_REFRESH_TOKEN_AUTH",
+				"ALLOW_USER_PASSWORD_AUTH",
+				"ALLOW_CUSTOM_AUTH",
+				"ALLOW_ADMIN_USER_PASSWORD_AUTH",
+			},
+		},
+		{
+			resourceType: userpoolclient.ResourceType,
+			property:     "AllowedOAuthFlows",
+			values: []string{
+				string(cognitoidentityprovider.OAuthFlowTypeCode),
+				string(cognitoidentityprovider.OAuthFlowTypeImplicit),
+				string(cognitoidentityprovider.OAuthFlowTypeClientCredentials),
+			},
+		},
+		{
+			resourceType: userpoolfederation.ResourceType,
+			property:     "ProviderType",
+			values: []string{
+				string(cognitoidentityprovider.IdentityProviderTypeTypeSaml),
+				string(cognitoidentityprovider.IdentityProviderTypeTypeFacebook),
+				string(cognitoidentityprovider.IdentityProviderTypeTypeGoogle),
+				string(cognitoidentityprovider.IdentityProviderTypeTypeLoginWithAmazon),
+				string(cognitoidentityprovider.IdentityProviderTypeTypeOidc),
+				"SignInWithApple",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		res, ok := registry.Get(test.resourceType)
+		if !ok {
+			t.Errorf("Test number: %d failed. %s isn't registered", i+1, test.resourceType)
+			continue
+		}
+
+		obj, err := res.Spec.Object()
+		if err != nil {
+			t.Errorf("Test number: %d failed. Error %s", i+1, err.Error())
+			continue
+		}
+
+		var prop *spec.Property
+		for _, p := range obj.Properties {
+			if p.Name == test.property {
+				prop = p
+			}
+		}
+		if prop == nil {
+			t.Errorf("Test number: %d failed. %s has no property %s", i+1, test.resourceType, test.property)
+			continue
+		}
+
+		for _, value := range test.values {
+			if !contains(prop.Enum, value) {
+				t.Errorf("Test number: %d failed. The enum of %s %s doesn't allow %s", i+1, test.resourceType, test.property, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Command cfn-gen generates the deployment template.yaml, the README property
+// and Fn::GetAtt tables and the JSON schema of every custom resource from its Spec,
+// and the template of the combined provider. The IAM actions used by the code of each resource are
+// found and checked against its policy, so that the policy stays least privilege.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+const usage = `Usage: cfn-gen [flags]
+
+Generates template.yaml, the property and attribute tables in README.md and
+schema/<type>.json for every custom resource, and cmd/provider/template.yaml. Must be run from
+the root of the repository or with -root.
+
+Flags:
+`
+
+func main() {
+	fs := flag.NewFlagSet("cfn-gen", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+
+	root := fs.String("root", ".", "Root of the repository")
+	check := fs.Bool("check", false, "Don't write any files, exit with 1 if any file isn't up to date")
+	actions := fs.Bool("actions", false, "Print the IAM actions used by every resource and exit")
+	fs.Parse(os.Args[1:])
+
+	if err := run(*root, *check, *actions); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// run generates all files in root. If check is true the files are only compared
+// and if actions is true the actions of every resource are printed instead.
+// Returns error.
+func run(root string, check bool, actions bool) error {
+	files := map[string][]byte{}
+	order := []string{}
+	add := func(path string, content []byte) {
+		files[path] = content
+		order = append(order, path)
+	}
+
+	problems := []string{}
+	for _, res := range registry.All() {
+		if res.Spec == nil {
+			return fmt.Errorf("%s has no Spec", res.Type)
+		}
+		dir := filepath.Join(root, filepath.FromSlash(res.Function))
+
+		found, err := findActions(dir)
+		if err != nil {
+			return err
+		}
+
+		if actions {
+			fmt.Printf("%s (%s)\n", res.Type, res.Function)
+			for _, action := range found {
+				fmt.Printf("  %s\n", action)
+			}
+			continue
+		}
+
+		if err := checkActions(res, found); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		tmpl, err := generateTemplate(res)
+		if err != nil {
+			return err
+		}
+		add(filepath.Join(dir, "template.yaml"), tmpl)
+
+		readmePath := filepath.Join(dir, "README.md")
+		readme, err := ioutil.ReadFile(readmePath)
+		if err != nil {
+			return fmt.Errorf("Couldn't read %s. Error %s", readmePath, err.Error())
+		}
+		if readme, err = generateReadme(res, readme); err != nil {
+			return err
+		}
+		add(readmePath, readme)
+
+		schema, err := res.Spec.Schema()
+		if err != nil {
+			return err
+		}
+		b, err := schema.JSON()
+		if err != nil {
+			return err
+		}
+		add(filepath.Join(root, "schema", res.Spec.FileName()), b)
+	}
+
+	switch {
+	case actions:
+		return nil
+
+	case len(problems) > 0:
+		return fmt.Errorf("The policies don't match the code.\n%s", strings.Join(problems, "\n"))
+	}
+
+	provider, err := generateProviderTemplate(registry.All())
+	if err != nil {
+		return err
+	}
+	add(filepath.Join(root, "cmd", "provider", "template.yaml"), provider)
+
+	outdated := []string{}
+	for _, path := range order {
+		current, _ := ioutil.ReadFile(path)
+		if bytes.Equal(current, files[path]) {
+			continue
+		}
+		outdated = append(outdated, path)
+
+		if !check {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("Couldn't create the folder of %s. Error %s", path, err.Error())
+			}
+			if err := ioutil.WriteFile(path, files[path], 0644); err != nil {
+				return fmt.Errorf("Couldn't write %s. Error %s", path, err.Error())
+			}
+			fmt.Printf("Wrote %s\n", path)
+		}
+	}
+
+	if check && len(outdated) > 0 {
+		return fmt.Errorf("The following files aren't up to date, run cfn-gen.\n%s", strings.Join(outdated, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+// The generated sections of a README are surrounded by <!-- cfn-gen:name --> and
+// <!-- /cfn-gen:name --> markers. Everything outside of the markers is left as is.
+const (
+	sectionProperties = "properties" // The table with the properties of the resource.
+	sectionObjects    = "objects"    // The tables of the nested properties.
+	sectionAttributes = "attributes" // The table with the Fn::GetAtt attributes.
+)
+
+// generateReadme takes res and readme and returns readme with the generated sections replaced.
+// Returns []byte and error.
+func generateReadme(res *registry.Resource, readme []byte) ([]byte, error) {
+	obj, err := res.Spec.Object()
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]string{
+		sectionProperties: propertiesSection(obj),
+		sectionObjects:    objectsSection(obj),
+		sectionAttributes: attributesSection(res.Spec.Attributes),
+	}
+
+	for _, name := range []string{sectionProperties, sectionObjects, sectionAttributes} {
+		if readme, err = replaceSection(readme, name, sections[name]); err != nil {
+			return nil, fmt.Errorf("%s/README.md: %s", res.Function, err.Error())
+		}
+	}
+	return readme, nil
+}
+
+// replaceSection takes readme and replaces the content between the markers of name with content.
+// Returns []byte and error.
+func replaceSection(readme []byte, name string, content string) ([]byte, error) {
+	start, end := []byte(fmt.Sprintf("<!-- cfn-gen:%s -->\n", name)), []byte(fmt.Sprintf("<!-- /cfn-gen:%s -->", name))
+
+	i := bytes.Index(readme, start)
+	j := bytes.Index(readme, end)
+	if i < 0 || j < i {
+		return nil, fmt.Errorf("Couldn't find the markers for the %s section", name)
+	}
+
+	out := append([]byte{}, readme[:i+len(start)]...)
+	out = append(out, content...)
+	return append(out, readme[j:]...), nil
+}
+
+// propertiesSection returns the table of the top level properties of obj.
+// Returns string.
+func propertiesSection(obj *spec.Object) string {
+	b := &strings.Builder{}
+	table(b, obj)
+	fmt.Fprintln(b, "| ServiceToken | String | The ARN of the lambda function for this Custom Resource | Yes |")
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "The [common properties](../../README.md#common-properties) `RoleArn`, `ExternalId` and `Region` are also supported.")
+	return b.String()
+}
+
+// objectsSection returns the tables of all nested objects of obj.
+// Returns string.
+func objectsSection(obj *spec.Object) string {
+	b, seen := &strings.Builder{}, map[string]bool{obj.Name: true}
+
+	obj.Walk(func(_ *spec.Object, prop *spec.Property) {
+		if prop.Object == nil || seen[prop.Object.Name] {
+			return
+		}
+		seen[prop.Object.Name] = true
+
+		fmt.Fprintf(b, "### %s Properties\n\n", prop.Object.Name)
+		table(b, prop.Object)
+		fmt.Fprintln(b)
+	})
+
+	return b.String()
+}
+
+// attributesSection returns the table of attributes.
+// Returns string.
+func attributesSection(attributes []spec.Attribute) string {
+	if len(attributes) == 0 {
+		return "This resource has no attributes that can be used with `Fn::GetAtt`.\n"
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "The following attributes can be used in CloudFormations `Fn::GetAtt` function.")
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "| Attribute name | Description |")
+	fmt.Fprintln(b, "| - | - |")
+	for _, attr := range attributes {
+		fmt.Fprintf(b, "| %s | %s |\n", attr.Name, attr.Description)
+	}
+	return b.String()
+}
+
+// table writes the property table of obj to b.
+func table(b *strings.Builder, obj *spec.Object) {
+	fmt.Fprintln(b, "| Property name | Type | Description | Required |")
+	fmt.Fprintln(b, "| - | - | - | - |")
+
+	for _, prop := range obj.Properties {
+		required := "No"
+		if prop.Required {
+			required = "Yes"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", prop.Name, typeName(prop), description(prop), required)
+	}
+}
+
+// typeName returns the type of prop as written in the README.
+// Returns string.
+func typeName(prop *spec.Property) string {
+	item := prop.ItemType
+	if prop.Object != nil {
+		item = prop.Object.Name
+	}
+
+	switch prop.Type {
+	case spec.TypeList:
+		return "List of " + item
+
+	case spec.TypeMap:
+		return "Map of " + item
+
+	case spec.TypeObject:
+		return item
+	}
+	return prop.Type
+}
+
+// description returns the description of prop including its valid values.
+// Returns string.
+func description(prop *spec.Property) string {
+	d := strings.TrimSuffix(prop.Description, ".")
+	if len(prop.Enum) > 0 {
+		values := []string{}
+		for _, v := range prop.Enum {
+			values = append(values, "**"+v+"**")
+		}
+
+		if d != "" {
+			d += ". "
+		}
+		d += "Valid values are " + join(values, "or")
+	}
+	return strings.Replace(d, "|", "\\|", -1)
+}
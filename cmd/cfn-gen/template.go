@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+// assumeRole is added to every policy so that the common RoleArn property can be used.
+var assumeRole = spec.Statement{
+	Comment:   "Only used when the RoleArn property is set on the custom resource.",
+	Actions:   []string{"sts:AssumeRole"},
+	Resources: []string{"arn:aws:iam::*:role/*"},
+}
+
+// defaultTimeout is the timeout in seconds of lambda functions whose Spec doesn't set one.
+const defaultTimeout = 60
+
+// deployTemplate is the template used to deploy the lambda function of a resource.
+var deployTemplate = template.Must(template.New("template").Funcs(template.FuncMap{"quote": quote}).Parse(`AWSTemplateFormatVersion: "2010-09-09"
+Description: {{ quote .Description }}
+
+Parameters:
+  Environment:
+    Description: "Environment"
+    Type: "String"
+    Default: "dev"
+
+  S3Bucket:
+    Description: "S3 bucket for lambda code"
+    Type: "String"
+
+  S3Key:
+    Description: "Key to where lambda code is located"
+    Type: "String"
+
+  FunctionName:
+    Description: "The Function name"
+    Type: "String"
+
+Resources:
+  Lambda:
+    Type: "AWS::Lambda::Function"
+    DependsOn:
+      - "Role"
+      - "LogGroup"
+    Properties:
+      FunctionName: !Sub "${FunctionName}-${AWS::Region}-${Environment}"
+      Description: !Sub "${FunctionName}-${AWS::Region}-${Environment}"
+      Role: !GetAtt "Role.Arn"
+      Handler: "handler"
+      Runtime: "go1.x"
+      Code:
+        S3Bucket: !Ref "S3Bucket"
+        S3Key: !Ref "S3Key"
+      Environment:
+        Variables:
+          ENVIRONMENT: !Ref "Environment"
+      Timeout: {{ .Timeout }}
+      MemorySize: 128
+
+  Role:
+    Type: "AWS::IAM::Role"
+    Properties:
+      RoleName: !Sub "${FunctionName}-role-${AWS::Region}-${Environment}"
+      ManagedPolicyArns:
+        - "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+      AssumeRolePolicyDocument:
+        Version: "2012-10-17"
+        Statement:
+          - Effect: "Allow"
+            Action: "sts:AssumeRole"
+            Principal:
+              Service: "lambda.amazonaws.com"
+      Policies:
+        - PolicyName: !Sub "${FunctionName}-policy"
+          PolicyDocument:
+            Version: "2012-10-17"
+            Statement:
+{{- range $i, $s := .Statements }}
+{{- if $i }}
+{{ end }}
+{{- range $s.Comment }}
+              # {{ . }}
+{{- end }}
+              - Effect: "Allow"
+                Action:
+{{- range $s.Actions }}
+                  - {{ quote . }}
+{{- end }}
+{{- if eq (len $s.Resources) 1 }}
+                Resource: {{ quote (index $s.Resources 0) }}
+{{- else }}
+                Resource:
+{{- range $s.Resources }}
+                  - {{ quote . }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+  LogGroup:
+    Type: "AWS::Logs::LogGroup"
+    Properties:
+      LogGroupName: !Sub "/aws/lambda/${FunctionName}-${AWS::Region}-${Environment}"
+      RetentionInDays: 90
+{{- if .Output }}
+
+Outputs:
+  ServiceToken:
+    Description: "The ARN to use as ServiceToken for all custom-cf resources"
+    Value: !GetAtt "Lambda.Arn"
+    Export:
+      Name: !Sub "${FunctionName}-${Environment}-service-token"
+{{- end }}
+`))
+
+// templateData is the data for deployTemplate.
+type templateData struct {
+	Description string
+	Statements  []statement
+	Timeout     int  // The timeout of the lambda function in seconds.
+	Output      bool // Export the ServiceToken, only done for the combined provider.
+}
+
+// statement is a policy statement with its comment split into lines.
+type statement struct {
+	Comment   []string
+	Actions   []string
+	Resources []string
+}
+
+// generateTemplate takes res and returns the deployment template of its lambda function.
+// Returns []byte and error.
+func generateTemplate(res *registry.Resource) ([]byte, error) {
+	data := templateData{Description: res.Spec.Description, Timeout: timeout(res)}
+	for _, s := range append(res.Spec.Policy, assumeRole) {
+		data.Statements = append(data.Statements, statement{Comment: wrap(s.Comment, 90), Actions: s.Actions, Resources: s.Resources})
+	}
+
+	return render(data)
+}
+
+// generateProviderTemplate takes resources and returns the deployment template of the combined
+// provider. Statements with the same resources and comment are merged into one.
+// Returns []byte and error.
+func generateProviderTemplate(resources []*registry.Resource) ([]byte, error) {
+	type group struct {
+		functions []string
+		comments  []string
+		actions   map[string]bool
+		resources []string
+	}
+
+	groups, order := map[string]*group{}, []string{}
+	for _, res := range resources {
+		for _, s := range res.Spec.Policy {
+			key := strings.Join(s.Resources, ",") + "#" + s.Comment
+			g, ok := groups[key]
+			if !ok {
+				g = &group{actions: map[string]bool{}, resources: s.Resources}
+				groups[key] = g
+				order = append(order, key)
+			}
+
+			if !contains(g.functions, res.Function) {
+				g.functions = append(g.functions, res.Function)
+			}
+			if s.Comment != "" && !contains(g.comments, s.Comment) {
+				g.comments = append(g.comments, s.Comment)
+			}
+			for _, action := range s.Actions {
+				g.actions[action] = true
+			}
+		}
+	}
+
+	// The combined provider runs every resource, so it gets the longest timeout.
+	data := templateData{Description: "Combined custom-cf CloudFormation Support for all Custom Resources", Timeout: defaultTimeout, Output: true}
+	for _, res := range resources {
+		if t := timeout(res); t > data.Timeout {
+			data.Timeout = t
+		}
+	}
+	for _, key := range order {
+		g := groups[key]
+
+		actions := []string{}
+		for action := range g.actions {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+
+		comment := strings.Join(append([]string{join(g.functions, "and") + "."}, g.comments...), " ")
+		data.Statements = append(data.Statements, statement{Comment: wrap(comment, 90), Actions: actions, Resources: g.resources})
+	}
+	data.Statements = append(data.Statements, statement{Comment: wrap(assumeRole.Comment, 90), Actions: assumeRole.Actions, Resources: assumeRole.Resources})
+
+	return render(data)
+}
+
+// timeout takes res and returns the timeout of its lambda function in seconds.
+// Returns int.
+func timeout(res *registry.Resource) int {
+	if res.Spec.Timeout > 0 {
+		return res.Spec.Timeout
+	}
+	return defaultTimeout
+}
+
+// render executes deployTemplate with data.
+// Returns []byte and error.
+func render(data templateData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := deployTemplate.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("Couldn't render template. Error %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// quote returns s as a double quoted YAML string. Strings containing
+// variables are returned as a short form Fn::Sub.
+// Returns string.
+func quote(s string) string {
+	q := fmt.Sprintf("%q", s)
+	if strings.Contains(s, "${") {
+		return "!Sub " + q
+	}
+	return q
+}
+
+// wrap splits s into lines no longer than width.
+// Returns []string.
+func wrap(s string, width int) []string {
+	lines, line := []string{}, ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && len(line)+len(word)+1 > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// join returns l joined with commas and conjunction before the last item.
+// Returns string.
+func join(l []string, conjunction string) string {
+	if len(l) < 2 {
+		return strings.Join(l, "")
+	}
+	return strings.Join(l[:len(l)-1], ", ") + " " + conjunction + " " + l[len(l)-1]
+}
+
+// contains returns true if l contains s.
+// Returns bool.
+func contains(l []string, s string) bool {
+	for _, item := range l {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+	"github.com/dwtechnologies/custom-cf/lib/template"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+const driftUsage = `Usage: custom-cf drift [flags] [template]
+
+Compares the properties of every custom resource in the template against the
+live resources in AWS. If -stack is set the template, parameters and physical
+IDs of the stack are used. Properties that can't be resolved are skipped.
+
+Exits with 0 if no drift was found, 3 if drift was found and 1 on errors.
+
+Flags:
+`
+
+// driftReport is the drift result of a single resource in the template.
+type driftReport struct {
+	LogicalID   string             `json:"LogicalResourceId"`
+	Type        string             `json:"ResourceType"`
+	Line        int                `json:"Line"`
+	Status      string             `json:"Status"`
+	Differences []drift.Difference `json:"Differences,omitempty"`
+	Skipped     []string           `json:"Skipped,omitempty"`
+	Note        string             `json:"Note,omitempty"`
+	Error       string             `json:"Error,omitempty"`
+}
+
+// runDrift runs the drift command with args.
+// Returns error.
+func runDrift(args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), driftUsage)
+		fs.PrintDefaults()
+	}
+
+	params, refs := stringList{}, stringList{}
+	stack := fs.String("stack", "", "Name or ID of the stack to read the template, parameters and physical IDs from")
+	region := fs.String("region", "", "AWS region to use, defaults to the region of the AWS config")
+	format := fs.String("format", "text", "Output format, text or json")
+	fs.Var(&params, "param", "Parameter value as Key=Value, can be set multiple times")
+	fs.Var(&refs, "ref", "Value for Ref or Fn::GetAtt as LogicalId=Value or LogicalId.Attribute=Value, can be set multiple times")
+
+	if err := fs.Parse(args); err != nil {
+		return &exitError{code: 2}
+	}
+
+	switch {
+	case *format != "text" && *format != "json":
+		return fmt.Errorf("Format needs to be either text or json")
+
+	case fs.NArg() > 1:
+		return fmt.Errorf("Only one template can be specified")
+
+	case fs.NArg() == 0 && *stack == "":
+		return fmt.Errorf("Either a template or -stack needs to be specified")
+	}
+
+	// Stop the requests to AWS on Ctrl-C.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+	}
+	if *region != "" {
+		cfg.Region = *region
+	}
+
+	// Point the stack and account reads at the same endpoints as the resources.
+	if cfg, err = awsconfig.Apply(cfg, nil); err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"AWS::Region":    cfg.Region,
+		"AWS::Partition": "aws",
+		"AWS::URLSuffix": "amazonaws.com",
+	}
+
+	// Read the template from file or from the stack.
+	body := []byte{}
+	if fs.NArg() == 1 {
+		if body, err = ioutil.ReadFile(fs.Arg(0)); err != nil {
+			return fmt.Errorf("Couldn't read template. Error %s", err.Error())
+		}
+	}
+
+	switch {
+	case *stack != "":
+		stackBody, err := readStack(ctx, cfg, *stack, values)
+		if err != nil {
+			return err
+		}
+		if len(body) == 0 {
+			body = stackBody
+		}
+
+	default:
+		account, err := accountID(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		values["AWS::AccountId"] = account
+	}
+
+	t, err := template.Parse(body)
+	if err != nil {
+		return err
+	}
+
+	// Parameter defaults are only used if the value isn't already known from the stack.
+	for name, param := range t.Parameters {
+		if _, ok := values[name]; !ok && param.Default != "" {
+			values[name] = param.Default
+		}
+	}
+
+	// Explicitly set values always win.
+	for _, list := range []stringList{params, refs} {
+		for _, val := range list {
+			parts := strings.SplitN(val, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("Value %s needs to be in the format Key=Value", val)
+			}
+			values[parts[0]] = parts[1]
+		}
+	}
+
+	reports := detectDrift(ctx, cfg, t, values)
+	if err := printDrift(os.Stdout, *format, reports); err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		switch report.Status {
+		case drift.StatusModified, drift.StatusDeleted:
+			return &exitError{code: 3}
+		}
+	}
+	for _, report := range reports {
+		if report.Error != "" {
+			return &exitError{code: 1}
+		}
+	}
+
+	return nil
+}
+
+// detectDrift runs drift detection on every custom resource in t that has support for it.
+// Returns []*driftReport.
+func detectDrift(ctx context.Context, cfg aws.Config, t *template.Template, values map[string]string) []*driftReport {
+	reports := []*driftReport{}
+
+	for _, res := range t.Resources {
+		impl, ok := registry.Get(res.Type)
+		if !ok {
+			continue
+		}
+
+		report := &driftReport{LogicalID: res.LogicalID, Type: res.Type, Line: res.Line, Status: drift.StatusNotChecked}
+		reports = append(reports, report)
+
+		// Not being able to check a resource type isn't an error, it's left as NOT_CHECKED.
+		if impl.Detect == nil {
+			report.Note = "Drift detection is not supported for this resource type"
+			continue
+		}
+
+		props, skipped, err := res.Resolve(values)
+		if err != nil {
+			report.Error = err.Error()
+			continue
+		}
+		report.Skipped = skipped
+
+		// Use the same account and region as the lambda would.
+		targetCfg, err := awsconfig.Apply(cfg, props)
+		if err != nil {
+			report.Error = err.Error()
+			continue
+		}
+
+		// The Ref of a custom resource is its physical ID, which is known from -stack or -ref.
+		result, err := impl.Detect(ctx, targetCfg, props, values[res.LogicalID])
+		if err != nil {
+			report.Error = err.Error()
+			continue
+		}
+
+		report.Status = result.Status
+		report.Differences = result.Differences
+	}
+
+	return reports
+}
+
+// printDrift writes reports to w in format.
+// Returns error.
+func printDrift(w io.Writer, format string, reports []*driftReport) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	for _, report := range reports {
+		fmt.Fprintf(w, "%s (%s) line %d: %s\n", report.LogicalID, report.Type, report.Line, report.Status)
+
+		for _, diff := range report.Differences {
+			fmt.Fprintf(w, "  ~ %s: declared %s, live %s\n", diff.Property, formatValue(diff.Declared), formatValue(diff.Live))
+		}
+		for _, path := range report.Skipped {
+			fmt.Fprintf(w, "  ? %s: skipped, value can't be resolved\n", path)
+		}
+		if report.Note != "" {
+			fmt.Fprintf(w, "  - %s\n", report.Note)
+		}
+		if report.Error != "" {
+			fmt.Fprintf(w, "  ! %s\n", report.Error)
+		}
+	}
+
+	return nil
+}
+
+// formatValue returns v formatted as JSON.
+// Returns string.
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// readStack takes ctx, cfg and stack and adds the stacks parameters, pseudo parameters
+// and physical IDs of its resources to values.
+// Returns the template body of the stack and error.
+func readStack(ctx context.Context, cfg aws.Config, stack string, values map[string]string) ([]byte, error) {
+	svc := cloudformation.New(cfg)
+
+	r := svc.DescribeStacksRequest(&cloudformation.DescribeStacksInput{StackName: &stack})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't describe stack %s. Error %s", stack, err.Error())
+	}
+	if len(resp.Stacks) == 0 {
+		return nil, fmt.Errorf("Stack %s doesn't exist", stack)
+	}
+
+	s := resp.Stacks[0]
+	stackID := aws.StringValue(s.StackId)
+	values["AWS::StackId"] = stackID
+	values["AWS::StackName"] = aws.StringValue(s.StackName)
+
+	// arn:aws:cloudformation:region:account:stack/name/id
+	if parts := strings.Split(stackID, ":"); len(parts) > 4 {
+		values["AWS::Partition"] = parts[1]
+		values["AWS::Region"] = parts[3]
+		values["AWS::AccountId"] = parts[4]
+	}
+
+	for _, param := range s.Parameters {
+		val := aws.StringValue(param.ParameterValue)
+		if param.ResolvedValue != nil {
+			val = *param.ResolvedValue
+		}
+		values[aws.StringValue(param.ParameterKey)] = val
+	}
+
+	if err := readStackResources(ctx, svc, stackID, values, nil); err != nil {
+		return nil, err
+	}
+
+	get := svc.GetTemplateRequest(&cloudformation.GetTemplateInput{
+		StackName:     &stackID,
+		TemplateStage: cloudformation.TemplateStageOriginal,
+	})
+	get.SetContext(ctx)
+	tmpl, err := get.Send()
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get template for stack %s. Error %s", stack, err.Error())
+	}
+
+	return []byte(aws.StringValue(tmpl.TemplateBody)), nil
+}
+
+// readStackResources adds the physical ID of every resource in the stack to values
+// keyed by its logical ID. This function is recursive so it will execute it self
+// if there is a nextToken. Leave nextToken as nil if it's the first run.
+// Returns error.
+func readStackResources(ctx context.Context, svc *cloudformation.CloudFormation, stackID string, values map[string]string, nextToken *string) error {
+	r := svc.ListStackResourcesRequest(&cloudformation.ListStackResourcesInput{
+		StackName: &stackID,
+		NextToken: nextToken,
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return fmt.Errorf("Couldn't list resources for stack %s. Error %s", stackID, err.Error())
+	}
+
+	for _, res := range resp.StackResourceSummaries {
+		if res.PhysicalResourceId != nil {
+			values[aws.StringValue(res.LogicalResourceId)] = *res.PhysicalResourceId
+		}
+	}
+
+	if resp.NextToken != nil {
+		return readStackResources(ctx, svc, stackID, values, resp.NextToken)
+	}
+	return nil
+}
+
+// accountID returns the ID of the AWS account that cfg belongs to.
+// Returns string and error.
+func accountID(ctx context.Context, cfg aws.Config) (string, error) {
+	r := sts.New(cfg).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return "", fmt.Errorf("Couldn't get AWS account ID. Error %s", err.Error())
+	}
+	return aws.StringValue(resp.Account), nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/lib/template"
+	"github.com/dwtechnologies/custom-cf/registry"
+)
+
+const lintUsage = `Usage: custom-cf lint [flags] template [template ...]
+
+Validates the properties of every custom resource in the templates in the same
+way as the lambda functions do, without calling AWS. Values are also checked
+against the allowed values and types in the spec of the resource. Properties
+that are set by intrinsic functions such as !Ref or !GetAtt are only known at
+deploy time and are skipped. Since the lambda functions stop at the first
+invalid property, only the first problem of every resource is reported.
+
+Exits with 0 if no problems were found and 1 otherwise.
+
+Flags:
+`
+
+// lintProblem is a problem found in a template.
+type lintProblem struct {
+	File      string `json:"File"`
+	Line      int    `json:"Line"`
+	LogicalID string `json:"LogicalResourceId"`
+	Type      string `json:"ResourceType"`
+	Property  string `json:"Property,omitempty"`
+	Message   string `json:"Message"`
+}
+
+// runLint runs the lint command with args.
+// Returns error.
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), lintUsage)
+		fs.PrintDefaults()
+	}
+
+	format := fs.String("format", "text", "Output format, text or json")
+
+	if err := fs.Parse(args); err != nil {
+		return &exitError{code: 2}
+	}
+
+	switch {
+	case *format != "text" && *format != "json":
+		return fmt.Errorf("Format needs to be either text or json")
+
+	case fs.NArg() == 0:
+		return fmt.Errorf("At least one template needs to be specified")
+	}
+
+	problems := []*lintProblem{}
+	for _, file := range fs.Args() {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Couldn't read template. Error %s", err.Error())
+		}
+
+		t, err := template.Parse(body)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err.Error())
+		}
+
+		problems = append(problems, lintTemplate(file, t)...)
+	}
+
+	if err := printLint(os.Stdout, *format, problems); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// lintTemplate validates every custom resource in t. file is only used in the problems.
+// Returns []*lintProblem.
+func lintTemplate(file string, t *template.Template) []*lintProblem {
+	problems := []*lintProblem{}
+
+	for _, res := range t.Resources {
+		problem := &lintProblem{File: file, Line: res.Line, LogicalID: res.LogicalID, Type: res.Type}
+
+		impl, ok := registry.Get(res.Type)
+		switch {
+		case !ok && isCustomCF(res.Type):
+			problem.Message = fmt.Sprintf("Unknown resource type %s", res.Type)
+			problems = append(problems, problem)
+			continue
+
+		case !ok || impl.Validate == nil:
+			continue
+		}
+
+		obj, err := impl.Spec.Object()
+		if err != nil {
+			problem.Message = err.Error()
+			problems = append(problems, problem)
+			continue
+		}
+
+		// Nothing is known about the stack, so every intrinsic function is replaced by a
+		// placeholder that is valid for the property. Otherwise the validation would stop
+		// at the first property that is set with !Ref, and most IDs are.
+		filled, skipped := *res, []string{}
+		filled.Properties = fill(res.Properties, "", &spec.Property{Type: spec.TypeObject, Object: obj}, &skipped).(map[string]interface{})
+
+		props, unresolved, err := filled.Resolve(map[string]string{})
+		if err != nil {
+			problem.Message = err.Error()
+			problems = append(problems, problem)
+			continue
+		}
+		skipped = append(skipped, unresolved...)
+
+		// The enum and type tags are checked first, since not every resource checks
+		// them again in Validate.
+		err = checkTags(props, obj)
+		if err == nil {
+			err = impl.Validate(props)
+		}
+		if err == nil {
+			continue
+		}
+
+		path := spec.ErrorPath(err)
+		if isSkipped(path, skipped) {
+			continue
+		}
+
+		problem.Property, problem.Message = path, err.Error()
+		if path != "" {
+			problem.Line = res.Pos(path)
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// fill takes the value v of prop at path and returns a copy of it where every intrinsic
+// function is replaced by a placeholder of the type of the property. The paths of the
+// replaced values are appended to skipped. Values that aren't properties are kept as is.
+// Returns interface{}.
+func fill(v interface{}, path string, prop *spec.Property, skipped *[]string) interface{} {
+	if template.IsIntrinsic(v) {
+		// AWS::NoValue removes the property and is resolved as usual.
+		if m := v.(map[string]interface{}); m["Ref"] == template.NoValue {
+			return v
+		}
+
+		*skipped = append(*skipped, path)
+		return placeholder(prop)
+	}
+
+	// item describes the items of a List or Map.
+	item := &spec.Property{Type: prop.ItemType, Object: prop.Object, Enum: prop.Enum}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, child := range val {
+			m[key] = child
+
+			switch {
+			case prop.Type == spec.TypeMap:
+				m[key] = fill(child, join(path, key), item, skipped)
+
+			case prop.Object != nil:
+				for _, p := range prop.Object.Properties {
+					if p.Name == key {
+						m[key] = fill(child, join(path, key), p, skipped)
+					}
+				}
+			}
+		}
+		return m
+
+	case []interface{}:
+		l := []interface{}{}
+		for i, child := range val {
+			l = append(l, fill(child, join(path, fmt.Sprint(i)), item, skipped))
+		}
+		return l
+	}
+
+	return v
+}
+
+// checkTags checks that the resolved properties props have the values that the enum
+// and type tags of obj allow.
+// Returns error.
+func checkTags(props json.RawMessage, obj *spec.Object) error {
+	var v interface{}
+	if err := json.Unmarshal(props, &v); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %w", err)
+	}
+	return checkTag(v, "", &spec.Property{Type: spec.TypeObject, Object: obj})
+}
+
+// checkTag checks the value v of prop at path and its children in the same way as fill
+// walks them. Keys are checked in order, so the same problem is reported every time.
+// Returns error.
+func checkTag(v interface{}, path string, prop *spec.Property) error {
+	// item describes the items of a List or Map.
+	item := &spec.Property{Type: prop.ItemType, Object: prop.Object, Enum: prop.Enum}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch {
+			case prop.Type == spec.TypeMap:
+				if err := checkTag(val[key], join(path, key), item); err != nil {
+					return err
+				}
+
+			case prop.Object != nil:
+				for _, p := range prop.Object.Properties {
+					if p.Name != key {
+						continue
+					}
+					if err := checkTag(val[key], join(path, key), p); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+	case []interface{}:
+		for i, child := range val {
+			if err := checkTag(child, join(path, fmt.Sprint(i)), item); err != nil {
+				return err
+			}
+		}
+
+	case string:
+		switch {
+		case len(prop.Enum) > 0 && !contains(prop.Enum, val):
+			return spec.Errorf(path, "%s needs to be one of %s, not %s", path, strings.Join(prop.Enum, ", "), val)
+
+		case prop.Type == spec.TypeBoolean && !strings.EqualFold(val, "true") && !strings.EqualFold(val, "false"):
+			return spec.Errorf(path, "%s needs to be true or false, not %s", path, val)
+
+		case prop.Type == spec.TypeInteger:
+			if _, err := strconv.Atoi(val); err != nil {
+				return spec.Errorf(path, "%s needs to be an integer, not %s", path, val)
+			}
+		}
+	}
+	return nil
+}
+
+// contains returns true if l contains s.
+// Returns bool.
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns a value of the type of prop that passes its validation.
+// Returns interface{}.
+func placeholder(prop *spec.Property) interface{} {
+	switch {
+	case prop.Type == spec.TypeList:
+		return []interface{}{}
+
+	case prop.Type == spec.TypeMap || prop.Type == spec.TypeObject:
+		return map[string]interface{}{}
+
+	case len(prop.Enum) > 0:
+		return prop.Enum[0]
+
+	case prop.Type == spec.TypeInteger:
+		return "0"
+
+	case prop.Type == spec.TypeBoolean:
+		return "false"
+	}
+	return "unresolved"
+}
+
+// join returns path and key joined with a dot.
+// Returns string.
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isCustomCF returns true if resourceType looks like one of the custom resources in this repository.
+// Returns bool.
+func isCustomCF(resourceType string) bool {
+	return strings.HasPrefix(resourceType, "Custom::Cognito") || strings.HasPrefix(resourceType, "Custom::IAMRole")
+}
+
+// isSkipped returns true if the value of path, any of its parents or any of its
+// children couldn't be resolved. The problem might not exist once it's resolved.
+// Returns bool.
+func isSkipped(path string, skipped []string) bool {
+	if path == "" {
+		return false
+	}
+
+	for _, s := range skipped {
+		if s == path || strings.HasPrefix(path, s+".") || strings.HasPrefix(s, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// printLint writes problems to w in format.
+// Returns error.
+func printLint(w io.Writer, format string, problems []*lintProblem) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(problems)
+	}
+
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s:%d: %s (%s): %s\n", p.File, p.Line, p.LogicalID, p.Type, p.Message)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dwtechnologies/custom-cf/lib/template"
+)
+
+func TestLintTemplate(t *testing.T) {
+	tests := []struct {
+		body     string
+		property string
+		line     int
+	}{
+		{
+			body: `
+Resources:
+  Client:
+    Type: Custom::CognitoUserPoolClient
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ClientName: web
+      GenerateSecret: true
+      AllowedOAuthFlows:
+        - code
+      CallbackURLs:
+        - https://example.com
+`,
+		},
+		{
+			body: `
+Resources:
+  Client:
+    Type: Custom::CognitoUserPoolClient
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ClientName: web
+      CallbackURLs:
+        - https://example.com
+        - http://example.com
+`,
+			property: "CallbackURLs.1",
+			line:     11,
+		},
+		{
+			body: `
+Resources:
+  Client:
+    Type: Custom::CognitoUserPoolClient
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ClientName: web
+      AllowedOAuthFlows:
+        - code
+        - password
+`,
+			property: "AllowedOAuthFlows.1",
+			line:     11,
+		},
+		{
+			body: `
+Resources:
+  Client:
+    Type: Custom::CognitoUserPoolClient
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ClientName: web
+      GenerateSecret: maybe
+`,
+			property: "GenerateSecret",
+			line:     9,
+		},
+		{
+			body: `
+Resources:
+  Federation:
+    Type: Custom::CognitoUserPoolFederation
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ProviderName: Twitter
+      ProviderType: Twitter
+`,
+			property: "ProviderType",
+			line:     9,
+		},
+		{
+			// The value is only known at deploy time.
+			body: `
+Resources:
+  Federation:
+    Type: Custom::CognitoUserPoolFederation
+    Properties:
+      ServiceToken: !Ref ServiceToken
+      UserPoolId: !Ref UserPool
+      ProviderName: Provider
+      ProviderType: !Ref ProviderType
+`,
+		},
+	}
+
+	for i, test := range tests {
+		tmpl, err := template.Parse([]byte(test.body))
+		if err != nil {
+			t.Errorf("Test number: %d failed. Error %s", i+1, err.Error())
+			continue
+		}
+
+		problems := lintTemplate("template.yaml", tmpl)
+		switch {
+		case test.property == "" && len(problems) > 0:
+			t.Errorf("Test number: %d failed. Expected no problems, got %s", i+1, problems[0].Message)
+
+		case test.property == "":
+
+		case len(problems) != 1:
+			t.Errorf("Test number: %d failed. Expected 1 problem, got %d", i+1, len(problems))
+
+		case problems[0].Property != test.property || problems[0].Line != test.line:
+			t.Errorf("Test number: %d failed. Expected %s on line %d, got %s on line %d (%s)", i+1, test.property, test.line, problems[0].Property, problems[0].Line, problems[0].Message)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Command custom-cf contains tooling for working with the custom resources in
+// this repository outside of CloudFormation.
+//
+// Usage:
+//
+//	custom-cf drift [flags] [template]
+//	custom-cf lint [flags] template [template ...]
+//	custom-cf new [flags] <service>/<name>
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: custom-cf <command> [flags] [arguments]
+
+Commands:
+  drift    Detect drift between a template and the live custom resources
+  lint     Validate the custom resources in templates without deploying them
+  new      Create a new custom resource from a scaffold
+
+Run "custom-cf <command> -h" for the flags of a command.
+`
+
+// exitError is returned by commands that want a specific exit code.
+type exitError struct {
+	code int
+	msg  string
+}
+
+func (e *exitError) Error() string {
+	return e.msg
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "drift":
+		err = runDrift(os.Args[2:])
+
+	case "lint":
+		err = runLint(os.Args[2:])
+
+	case "new":
+		err = runNew(os.Args[2:])
+
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		code := 1
+		if e, ok := err.(*exitError); ok {
+			code = e.code
+		}
+		if err.Error() != "" {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		os.Exit(code)
+	}
+}
+
+// stringList is a flag that can be set multiple times.
+type stringList []string
+
+func (s *stringList) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringList) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const newUsage = `Usage: custom-cf new [flags] <service>/<name>
+
+Creates a new custom resource in the folder <service>/<name>, such as
+cognito/userpool-group. Must be run from the root of the repository.
+
+The resource has a Handler, create, update and delete stubs, validation, a
+Spec, a test using lib/testkit, template.yaml and README.md.
+
+Flags:
+`
+
+// scaffold contains the templates of the files of a new resource. The files are
+// named as in the resource with a .tmpl suffix.
+//
+//go:embed scaffold
+var scaffold embed.FS
+
+// sdkService is an AWS SDK service that new resources can use.
+type sdkService struct {
+	Package string // The package name, such as cognitoidentityprovider.
+	Client  string // The client type in the package.
+	Factory string // The method on the lib/awsclient Factory that creates the client.
+}
+
+// sdkServices contains the services that lib/awsclient can create clients for.
+// Add a method to the Factory in lib/awsclient before adding a service here.
+var sdkServices = map[string]sdkService{
+	"cognitoidentity":         {Package: "cognitoidentity", Client: "CognitoIdentity", Factory: "CognitoIdentity"},
+	"cognitoidentityprovider": {Package: "cognitoidentityprovider", Client: "CognitoIdentityProvider", Factory: "CognitoIdentityProvider"},
+	"iam":                     {Package: "iam", Client: "IAM", Factory: "IAM"},
+	"route53":                 {Package: "route53", Client: "Route53", Factory: "Route53"},
+	"secretsmanager":          {Package: "secretsmanager", Client: "SecretsManager", Factory: "SecretsManager"},
+}
+
+var (
+	dirRegexp  = regexp.MustCompile(`^[a-z][a-z0-9]*/[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+	typeRegexp = regexp.MustCompile(`^Custom::[A-Z][A-Za-z0-9]+$`)
+)
+
+// scaffoldData is the data for the scaffold templates.
+type scaffoldData struct {
+	Dir         string     // The folder of the resource, such as cognito/userpool-group.
+	Name        string     // The name of the resource, such as userpool-group.
+	Function    string     // The default function name, such as cognito-userpool-group.
+	Package     string     // The package name, such as userpoolgroup.
+	Type        string     // The ResourceType, such as Custom::CognitoUserPoolGroup.
+	Struct      string     // The properties struct, such as CognitoUserPoolGroup.
+	Receiver    string     // The receiver name of the properties struct.
+	Description string     // The Description of the deployment template.
+	SDK         sdkService // The AWS SDK service that the resource uses.
+}
+
+// runNew runs the new command with args.
+// Returns error.
+func runNew(args []string) error {
+	flags := flag.NewFlagSet("new", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), newUsage)
+		flags.PrintDefaults()
+	}
+
+	resourceType := flags.String("type", "", "The ResourceType, such as Custom::CognitoUserPoolGroup")
+	sdk := flags.String("sdk-service", "", "The AWS SDK service package that the resource uses, such as cognitoidentityprovider")
+	description := flags.String("description", "", "Description of the deployment template, defaults to the ResourceType")
+
+	// Allow the folder before the flags, as in custom-cf new cognito/userpool-group -type Custom::Foo.
+	dir := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		dir, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return &exitError{code: 2}
+	}
+	if dir == "" && flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	service, ok := sdkServices[*sdk]
+	switch {
+	case !dirRegexp.MatchString(dir):
+		return fmt.Errorf("The folder needs to be <service>/<name> in lower case, such as cognito/userpool-group")
+
+	case !typeRegexp.MatchString(*resourceType):
+		return fmt.Errorf("-type needs to be a ResourceType such as Custom::CognitoUserPoolGroup")
+
+	case !ok:
+		names := []string{}
+		for name := range sdkServices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("-sdk-service needs to be one of %s", strings.Join(names, ", "))
+	}
+
+	if _, err := os.Stat("go.mod"); err != nil {
+		return fmt.Errorf("custom-cf new must be run from the root of the repository")
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+
+	name := path.Base(dir)
+	data := &scaffoldData{
+		Dir:         dir,
+		Name:        name,
+		Function:    strings.Replace(dir, "/", "-", -1),
+		Package:     strings.Replace(name, "-", "", -1),
+		Type:        *resourceType,
+		Struct:      strings.TrimPrefix(*resourceType, "Custom::"),
+		Receiver:    strings.ToLower(strings.TrimPrefix(*resourceType, "Custom::")[:1]),
+		Description: *description,
+		SDK:         service,
+	}
+	if data.Description == "" {
+		data.Description = data.Struct + " CloudFormation Support"
+	}
+
+	files, err := renderScaffold(data)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+
+	for _, file := range names {
+		target := filepath.Join(filepath.FromSlash(dir), filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("Couldn't create the folder of %s. Error %s", target, err.Error())
+		}
+		if err := ioutil.WriteFile(target, files[file], 0644); err != nil {
+			return fmt.Errorf("Couldn't write %s. Error %s", target, err.Error())
+		}
+		fmt.Printf("Wrote %s\n", target)
+	}
+
+	fmt.Printf(`
+Next steps:
+  1. Add %[1]s to registry/registry.go with its Handler, Validate and Spec.
+  2. Replace the properties and implement create, update and delete.
+  3. Add the actions that the resource uses to the Policy of its Spec.
+  4. Run "make generate" to update template.yaml, README.md and the schema.
+`, data.Type)
+	return nil
+}
+
+// renderScaffold takes data and renders all files of a new resource.
+// Returns the files keyed by their path in the resource folder and error.
+func renderScaffold(data *scaffoldData) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	err := fs.WalkDir(scaffold, "scaffold", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		b, err := scaffold.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("Couldn't read %s. Error %s", name, err.Error())
+		}
+
+		tmpl, err := template.New(name).Parse(string(b))
+		if err != nil {
+			return fmt.Errorf("Couldn't parse %s. Error %s", name, err.Error())
+		}
+
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, data); err != nil {
+			return fmt.Errorf("Couldn't render %s. Error %s", name, err.Error())
+		}
+
+		file := strings.TrimSuffix(strings.TrimPrefix(name, "scaffold/"), ".tmpl")
+		out := buf.Bytes()
+		if strings.HasSuffix(file, ".go") {
+			if out, err = format.Source(out); err != nil {
+				return fmt.Errorf("Couldn't format %s. Error %s", file, err.Error())
+			}
+		}
+
+		files[file] = out
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,81 @@
+// Command provider is a single lambda function that handles every custom resource
+// in this repository. The request is dispatched to the implementation registered
+// for its ResourceType, so one deployment is enough instead of one per resource.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	// External
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/registry"
+	l "github.com/nuttmeister/llogger"
+
+	// External - AWS
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const (
+	service  = "custom-cf"
+	function = "provider"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// handler takes context.Context and *events.Request and dispatches the request
+// to the resource registered for the requests ResourceType.
+// Returns error.
+func handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
+	res, ok := registry.Get(req.ResourceType)
+	if ok && res.Handler != nil {
+		return res.Handler(ctx, req)
+	}
+
+	log := l.Create(ctx, l.Input{
+		"service":           service,
+		"function":          function,
+		"env":               os.Getenv("ENVIRONMENT"),
+		"stackId":           req.StackID,
+		"requestType":       req.RequestType,
+		"requestId":         req.RequestID,
+		"resourceType":      req.ResourceType,
+		"logicalResourceId": req.LogicalResourceID,
+		"lambda":            req.Lambda,
+	})
+
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		if err := req.SendWithContext(ctx, "NotAviable", nil, err); err != nil {
+			log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		}
+		return err
+	}
+
+	// If Delete is run on a resource type we don't support it can't have been
+	// created by us. So let the delete succeed so that rollbacks don't get stuck.
+	if req.RequestType == events.RequestDelete {
+		log.Print(l.Input{"loglevel": "warning", "message": fmt.Sprintf("Ignoring Delete of unsupported ResourceType %s", req.ResourceType)})
+		return req.SendWithContext(ctx, req.PhysicalResourceID, nil, nil)
+	}
+
+	err := fmt.Errorf("Unsupported ResourceType %s", req.ResourceType)
+	log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+	if err := req.SendWithContext(ctx, "NotAviable", nil, err); err != nil {
+		log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+	}
+	return err
+}
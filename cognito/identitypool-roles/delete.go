@@ -1,10 +1,14 @@
-package main
+package identitypoolroles
 
-import "github.com/dwtechnologies/custom-cf/lib/events"
+import (
+	"context"
+
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
 
 // deleteRoles will delete the roles configuration for the IdentityPool and set empty roles.
 // Returns error.
-func (c *config) deleteRoles(req *events.Request) error {
+func (c *config) deleteRoles(ctx context.Context, req *events.Request) error {
 	// If IdentityPoolID is empty we must assume that an delete was sent
 	// on a failed resource creation. So just return nil.
 	switch {
@@ -12,5 +16,5 @@ func (c *config) deleteRoles(req *events.Request) error {
 		return nil
 	}
 
-	return c.setRoles(req, true)
+	return c.setRoles(ctx, req, true)
 }
@@ -0,0 +1,188 @@
+// Package identitypoolroles handles the Custom::CognitoIdentityPoolRoles resource.
+package identitypoolroles
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	l "github.com/nuttmeister/llogger"
+
+	// External - AWS
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity"
+)
+
+// http client timeout in seconds.
+const (
+	service      = "custom-cf"
+	function     = "identitypool-roles"
+	ResourceType = "Custom::CognitoIdentityPoolRoles"
+	httpTimeout  = 30
+)
+
+type config struct {
+	log *l.Client
+	svc *cognitoidentity.CognitoIdentity
+
+	physicalID            string             // The physical ID to use for the resource.
+	resourceProperties    *IdentityPoolRoles // The new resource data from the template.
+	oldResourceProperties *IdentityPoolRoles // The old resource data, only on updates.
+}
+
+// IdentityPoolRoles contains the fields for setting IdentityPool Roles.
+type IdentityPoolRoles struct {
+	Roles          map[string]string `json:"Roles,omitempty" doc:"Set the default roles ARNs. Currently supports keys **authenticated** and **unauthenticated**"`
+	RoleMappings   []RoleMapping     `json:"RoleMappings,omitempty" doc:"The role mapping for a specific Identity Provider. Up to 25 rules can be specified per Identity Provider"`
+	IdentityPoolID string            `json:"IdentityPoolId" cfn:"required,createOnly" doc:"The ID of the IdentityPool to set the roles for"`
+}
+
+// RoleMapping contains the role mappings for a identity provider.
+type RoleMapping struct {
+	IdentityProvider        string             `json:"IdentityProvider" cfn:"required" doc:"The Identity Provider to use for the mapping. When using UserPool the Identity Provider should be cognito-idp.${Region}.amazonaws.com/${UserPoolId}:${UserPoolClientId}"`
+	Type                    string             `json:"Type" cfn:"required,enum=Token|Rules" doc:"Where **Token** will use cognito:roles and cognito:preferred_role claims. Where **Rules** will match the claims from the token to a role"`
+	AmbiguousRoleResolution string             `json:"AmbiguousRoleResolution" cfn:"required,enum=AuthenticatedRole|Deny" doc:"Specify the action to be taken if there is no match if using type **Rules** or there is no preferred_role when using **Token** and multiple roles"`
+	RulesConfiguration      RulesConfiguration `json:"RulesConfiguration" doc:"The rules for mapping roles. This is required when choosing type **Rules**"`
+}
+
+type RulesConfiguration struct {
+	Rules []Rule `json:"Rules,omitempty" doc:"List of rules"`
+}
+
+// Rule contains the rules if you're using rules based role mapping.
+type Rule struct {
+	Claim     string `json:"Claim" cfn:"required" doc:"The Claim to match"`
+	MatchType string `json:"MatchType" cfn:"enum=Equals|Contains|StartsWith|NotEqual" doc:"How to match the claim against the value"`
+	Value     string `json:"Value" cfn:"required" doc:"The value to match against the claim"`
+	RoleArn   string `json:"RoleArn" cfn:"required" doc:"The ARN to the role to assign"`
+}
+
+// Handler takes context.Context and *events.Request.
+// Returns error.
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
+	// Create the config.
+	c := createConfig(ctx, req)
+	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
+	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
+
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Create the Cognit service.
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
+	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Set physical ID
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s-roles", c.resourceProperties.IdentityPoolID))
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
+
+	// create, update or delete the userpool federation.
+	err := c.run(awsCtx, req)
+	if err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Send the result to the pre-signed s3 url.
+	if err := req.SendWithContext(ctx, c.physicalID, nil, err); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
+// Returns error.
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
+	if err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
+			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		}
+	}
+	return err
+}
+
+// createConfig takes ctx and req and creates a config that contains the logger
+// and the cognito aws service.
+// Returns *config and error.
+func createConfig(ctx context.Context, req *events.Request) *config {
+	return &config{
+		log: l.Create(ctx, l.Input{
+			"service":               service,
+			"function":              function,
+			"env":                   os.Getenv("ENVIRONMENT"),
+			"stackId":               req.StackID,
+			"requestType":           req.RequestType,
+			"requestId":             req.RequestID,
+			"resourceType":          req.ResourceType,
+			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
+			"resourceProperties":    req.ResourceProperties,
+			"oldResourceProperties": req.OldResourceProperties,
+		}),
+		physicalID:            "NotAviable",
+		resourceProperties:    &IdentityPoolRoles{},
+		oldResourceProperties: &IdentityPoolRoles{},
+	}
+}
+
+// Creates the CognitoIdentity Service for the target account and region in req.
+// Returns error.
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
+	if err != nil {
+		return err
+	}
+
+	c.svc = clients.CognitoIdentity()
+	return nil
+}
+
+// run will either set or delete the specified roles settings on the IdentityPool.
+// Returns map[string]string and error.
+func (c *config) run(ctx context.Context, req *events.Request) error {
+	// Check for the correct ResourceType
+	if req.ResourceType != ResourceType {
+		return fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
+	}
+
+	switch {
+	// If Delete is run on the stack.
+	case req.RequestType == "Delete":
+		return c.deleteRoles(ctx, req)
+
+	// If Update is run on the stack.
+	case req.RequestType == "Update":
+		return c.setRoles(ctx, req, false)
+
+	// If Create is run on the stack.
+	case req.RequestType == "Create":
+		return c.setRoles(ctx, req, false)
+	}
+
+	return fmt.Errorf("Didn't get RequestType Create, Update or Delete")
+}
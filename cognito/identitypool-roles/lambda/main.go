@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	identitypoolroles "github.com/dwtechnologies/custom-cf/cognito/identitypool-roles"
+)
+
+func main() {
+	lambda.Start(identitypoolroles.Handler)
+}
@@ -1,6 +1,7 @@
-package main
+package identitypoolroles
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity"
@@ -11,7 +12,7 @@ import (
 // setRoles will set the roles specified by req.
 // If defaults is true the default roles will be set.
 // Returns error.
-func (c *config) setRoles(req *events.Request, defaults bool) error {
+func (c *config) setRoles(ctx context.Context, req *events.Request, defaults bool) error {
 	props := c.resourceProperties
 	if defaults {
 		props = &IdentityPoolRoles{IdentityPoolID: c.resourceProperties.IdentityPoolID}
@@ -22,9 +23,8 @@ func (c *config) setRoles(req *events.Request, defaults bool) error {
 		props.Roles = map[string]string{}
 	}
 
-	switch {
-	case props.IdentityPoolID == "":
-		return fmt.Errorf("No UserPoolId specified")
+	if err := props.validate(); err != nil {
+		return err
 	}
 
 	// Create the input for the request.
@@ -38,22 +38,7 @@ func (c *config) setRoles(req *events.Request, defaults bool) error {
 		input.RoleMappings = map[string]cognitoidentity.RoleMapping{}
 	}
 
-	// If RoleMappings is set validate the basic input.
 	for _, mapping := range props.RoleMappings {
-		switch {
-		case mapping.IdentityProvider == "":
-			return fmt.Errorf("No IdentityProvider set in RoleMappings")
-
-		case mapping.Type != "Token" && mapping.Type != "Rules":
-			return fmt.Errorf("Type is not valid in RoleMappings. Valid values are Token or Rules")
-
-		case mapping.AmbiguousRoleResolution != "AuthenticatedRole" && mapping.AmbiguousRoleResolution != "Deny":
-			return fmt.Errorf("AmbiguousRoleResolution is not valid in RoleMappings. Valid values are AuthenticatedRole or Deny")
-
-		case mapping.Type == "Rules" && mapping.RulesConfiguration.Rules == nil:
-			return fmt.Errorf("No Rules set in RoleMappings and Type is Rules")
-		}
-
 		input.RoleMappings[mapping.IdentityProvider] = cognitoidentity.RoleMapping{
 			Type:                    cognitoidentity.RoleMappingType(mapping.Type),
 			AmbiguousRoleResolution: cognitoidentity.AmbiguousRoleResolutionType(mapping.AmbiguousRoleResolution),
@@ -64,24 +49,9 @@ func (c *config) setRoles(req *events.Request, defaults bool) error {
 			r := input.RoleMappings[mapping.IdentityProvider]
 			r.RulesConfiguration = &cognitoidentity.RulesConfigurationType{Rules: []cognitoidentity.MappingRule{}}
 
-			// Validate rules.
-			for i, _ := range mapping.RulesConfiguration.Rules {
+			for i := range mapping.RulesConfiguration.Rules {
 				rule := mapping.RulesConfiguration.Rules[i]
 
-				switch {
-				case rule.Claim == "":
-					return fmt.Errorf("No Claim set in Rules")
-
-				case rule.MatchType != "Equals" && rule.MatchType != "Contains" && rule.MatchType != "StarsWith" && rule.MatchType != "NotEqual":
-					return fmt.Errorf("MatchType is not valid in Rules. Valid values are Equals, Contains, StarsWith or NotEqual")
-
-				case rule.Value == "":
-					return fmt.Errorf("No Value set in Rules")
-
-				case rule.RoleArn == "":
-					return fmt.Errorf("No RoleArn set in Rules")
-				}
-
 				r.RulesConfiguration.Rules = append(r.RulesConfiguration.Rules, cognitoidentity.MappingRule{
 					Claim:     &rule.Claim,
 					MatchType: cognitoidentity.MappingRuleMatchType(rule.MatchType),
@@ -96,9 +66,11 @@ func (c *config) setRoles(req *events.Request, defaults bool) error {
 	}
 
 	// Send the request.
-	_, err := c.svc.SetIdentityPoolRolesRequest(input).Send()
+	r := c.svc.SetIdentityPoolRolesRequest(input)
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to set Identity Pool Roles. Error %s", err.Error())
+		return fmt.Errorf("Failed to set Identity Pool Roles. Error %w", err)
 	}
 
 	return nil
@@ -0,0 +1,24 @@
+package identitypoolroles
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito IdentityPool Role Mappings CloudFormation Support",
+	Properties:  IdentityPoolRoles{},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-identity:SetIdentityPoolRoles",
+			},
+			Resources: []string{"arn:aws:cognito-identity:*:${AWS::AccountId}:identitypool/*"},
+		},
+		{
+			Comment:   "The roles to pass are only known from the templates using it.",
+			Actions:   []string{"iam:PassRole"},
+			Resources: []string{"*"},
+			Implicit:  true,
+		},
+	},
+}
@@ -0,0 +1,65 @@
+package identitypoolroles
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &IdentityPoolRoles{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the roles and role mappings.
+// Returns error.
+func (r *IdentityPoolRoles) validate() error {
+	if r.IdentityPoolID == "" {
+		return spec.Errorf("IdentityPoolId", "No IdentityPoolId specified")
+	}
+
+	// If RoleMappings is set validate the basic input.
+	for i, mapping := range r.RoleMappings {
+		path := fmt.Sprintf("RoleMappings.%d", i)
+
+		switch {
+		case mapping.IdentityProvider == "":
+			return spec.Errorf(path+".IdentityProvider", "No IdentityProvider set in RoleMappings")
+
+		case mapping.Type != "Token" && mapping.Type != "Rules":
+			return spec.Errorf(path+".Type", "Type is not valid in RoleMappings. Valid values are Token or Rules")
+
+		case mapping.AmbiguousRoleResolution != "AuthenticatedRole" && mapping.AmbiguousRoleResolution != "Deny":
+			return spec.Errorf(path+".AmbiguousRoleResolution", "AmbiguousRoleResolution is not valid in RoleMappings. Valid values are AuthenticatedRole or Deny")
+
+		case mapping.Type == "Rules" && mapping.RulesConfiguration.Rules == nil:
+			return spec.Errorf(path+".RulesConfiguration", "No Rules set in RoleMappings and Type is Rules")
+		}
+
+		for j, rule := range mapping.RulesConfiguration.Rules {
+			path := fmt.Sprintf("%s.RulesConfiguration.Rules.%d", path, j)
+
+			switch {
+			case rule.Claim == "":
+				return spec.Errorf(path+".Claim", "No Claim set in Rules")
+
+			case rule.MatchType != "Equals" && rule.MatchType != "Contains" && rule.MatchType != "StartsWith" && rule.MatchType != "NotEqual":
+				return spec.Errorf(path+".MatchType", "MatchType is not valid in Rules. Valid values are Equals, Contains, StartsWith or NotEqual")
+
+			case rule.Value == "":
+				return spec.Errorf(path+".Value", "No Value set in Rules")
+
+			case rule.RoleArn == "":
+				return spec.Errorf(path+".RoleArn", "No RoleArn set in Rules")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package userpoolclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// cognitoPrincipal is the service that assumes the analytics role.
+const cognitoPrincipal = "cognito-idp.amazonaws.com"
+
+var (
+	// applicationArnRegexp matches the ARN of a Pinpoint app, in any region.
+	applicationArnRegexp = regexp.MustCompile(`^arn:aws[a-z-]*:mobiletargeting:[a-z0-9-]+:\d{12}:apps/[A-Za-z0-9]+$`)
+
+	// roleArnRegexp matches the ARN of a role, the name of the role is the last part of its path.
+	roleArnRegexp = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/(?:[\w+=,.@-]+/)*([\w+=,.@-]+)$`)
+
+	// analyticsActions are the actions that Cognito uses the analytics role for, with the
+	// resource of the Pinpoint app that each action is used on.
+	analyticsActions = []analyticsAction{
+		{name: "mobiletargeting:PutEvents", resource: "/events"},
+		{name: "mobiletargeting:UpdateEndpoint", resource: "/endpoints/*"},
+	}
+)
+
+// analyticsAction is an action that Cognito uses the analytics role for.
+type analyticsAction struct {
+	name     string
+	resource string // Appended to ApplicationArn to get the ARN that the action is used on.
+}
+
+// analyticsSettings is AnalyticsConfiguration as Cognito has it. The AWS SDK in use
+// doesn't know ApplicationArn, so it's sent with awsclient.WithParams.
+type analyticsSettings struct {
+	ApplicationArn string `json:"ApplicationArn,omitempty"`
+	ApplicationID  string `json:"ApplicationId,omitempty"`
+	ExternalID     string `json:"ExternalId,omitempty"`
+	RoleArn        string `json:"RoleArn,omitempty"`
+	UserDataShared *bool  `json:"UserDataShared,omitempty"`
+}
+
+// validate validates the analytics configuration without calling AWS.
+// Returns error.
+func (a *AnalyticsConfigurationType) validate() error {
+	switch {
+	case a.ApplicationArn == "" && a.ApplicationID == "":
+		return spec.Errorf("AnalyticsConfiguration", "AnalyticsConfiguration needs ApplicationArn or ApplicationId")
+
+	case a.ApplicationArn != "" && a.ApplicationID != "":
+		return spec.Errorf("AnalyticsConfiguration.ApplicationId", "AnalyticsConfiguration can't have both ApplicationArn and ApplicationId")
+
+	case a.ApplicationArn != "" && !applicationArnRegexp.MatchString(a.ApplicationArn):
+		return spec.Errorf("AnalyticsConfiguration.ApplicationArn", "AnalyticsConfiguration.ApplicationArn needs to be the ARN of a Pinpoint app")
+
+	case a.ApplicationID != "" && (a.RoleArn == "" || a.ExternalID == ""):
+		return spec.Errorf("AnalyticsConfiguration.RoleArn", "AnalyticsConfiguration.ApplicationId requires RoleArn and ExternalId")
+
+	case a.RoleArn != "" && !roleArnRegexp.MatchString(a.RoleArn):
+		return spec.Errorf("AnalyticsConfiguration.RoleArn", "AnalyticsConfiguration.RoleArn needs to be the ARN of a role")
+	}
+
+	switch a.UserDataShared {
+	case "", "true", "false":
+	default:
+		return spec.Errorf("AnalyticsConfiguration.UserDataShared", "AnalyticsConfiguration.UserDataShared needs to be true or false")
+	}
+	return nil
+}
+
+// params returns the analytics configuration as it's sent to Cognito.
+// Returns *analyticsSettings.
+func (a *AnalyticsConfigurationType) params() *analyticsSettings {
+	shared := a.UserDataShared == "true"
+	settings := &analyticsSettings{
+		ApplicationArn: a.ApplicationArn,
+		ExternalID:     a.ExternalID,
+		RoleArn:        a.RoleArn,
+		UserDataShared: &shared,
+	}
+
+	// Cognito returns both, but ApplicationArn is the one to send if it's known.
+	if a.ApplicationArn == "" {
+		settings.ApplicationID = a.ApplicationID
+	}
+	return settings
+}
+
+// analyticsProblems takes cl and checks that the analytics role of cl can be assumed by
+// Cognito and is allowed to send events to the Pinpoint app. Without RoleArn Cognito uses
+// its service-linked role, so there is nothing to check.
+// Returns []string and error.
+func (c *config) analyticsProblems(ctx context.Context, cl *Client) ([]string, error) {
+	a := cl.AnalyticsConfiguration
+	if a == nil || a.RoleArn == "" {
+		return nil, nil
+	}
+
+	m := roleArnRegexp.FindStringSubmatch(a.RoleArn)
+	if m == nil {
+		return []string{fmt.Sprintf("AnalyticsConfiguration.RoleArn %s isn't the ARN of a role", a.RoleArn)}, nil
+	}
+
+	r := c.iam.GetRoleRequest(&iam.GetRoleInput{RoleName: &m[1]})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	switch {
+	case awserrors.IsNotFound(err):
+		return []string{fmt.Sprintf("AnalyticsConfiguration.RoleArn %s doesn't exist in the account of the UserPool", a.RoleArn)}, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("Couldn't get role %s. Error %w", a.RoleArn, err)
+
+	// A role with the same name in another account or path isn't the role.
+	case *resp.Role.Arn != a.RoleArn:
+		return []string{fmt.Sprintf("AnalyticsConfiguration.RoleArn %s doesn't exist in the account of the UserPool", a.RoleArn)}, nil
+	}
+
+	problems := []string{}
+	if problem := trustProblem(a, *resp.Role.AssumeRolePolicyDocument); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	for _, action := range analyticsActions {
+		problem, err := c.simulateAction(ctx, a, action)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, nil
+}
+
+// simulateAction takes a and action and returns why the analytics role isn't allowed action,
+// empty string is returned if it is. The action is simulated on the resource of the Pinpoint
+// app that Cognito uses it on, or on every resource if only ApplicationId is known.
+// Returns string and error.
+func (c *config) simulateAction(ctx context.Context, a *AnalyticsConfigurationType, action analyticsAction) (string, error) {
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: &a.RoleArn,
+		ActionNames:     []string{action.name},
+	}
+	if a.ApplicationArn != "" {
+		input.ResourceArns = []string{a.ApplicationArn + action.resource}
+	}
+
+	r := c.iam.SimulatePrincipalPolicyRequest(input)
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return "", fmt.Errorf("Couldn't simulate the policies of role %s. Error %w", a.RoleArn, err)
+	}
+
+	for _, result := range resp.EvaluationResults {
+		if result.EvalDecision != iam.PolicyEvaluationDecisionTypeAllowed {
+			return fmt.Sprintf("AnalyticsConfiguration.RoleArn %s isn't allowed %s (%s)", a.RoleArn, action.name, result.EvalDecision), nil
+		}
+	}
+	return "", nil
+}
+
+// trustPolicy is the part of a trust policy that is needed to tell if Cognito can assume the role.
+type trustPolicy struct {
+	Statement statements `json:"Statement"`
+}
+
+// trustStatement is a statement of a trust policy.
+type trustStatement struct {
+	Effect    string                           `json:"Effect"`
+	Action    stringList                       `json:"Action"`
+	Principal principal                        `json:"Principal"`
+	Condition map[string]map[string]stringList `json:"Condition"`
+}
+
+// principal is the principal of a statement, which can be "*" for everyone.
+type principal struct {
+	Any     bool       `json:"-"`
+	Service stringList `json:"Service"`
+}
+
+// UnmarshalJSON takes b and unmarshals a principal or "*".
+// Returns error.
+func (p *principal) UnmarshalJSON(b []byte) error {
+	if string(b) == `"*"` {
+		p.Any = true
+		return nil
+	}
+
+	type plain principal
+	return json.Unmarshal(b, (*plain)(p))
+}
+
+// statements are the statements of a policy, which can be a single statement or a list.
+type statements []trustStatement
+
+// UnmarshalJSON takes b and unmarshals a statement or a list of statements.
+// Returns error.
+func (s *statements) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '{' {
+		st := trustStatement{}
+		if err := json.Unmarshal(b, &st); err != nil {
+			return err
+		}
+		*s = statements{st}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]trustStatement)(s))
+}
+
+// stringList is a value of a policy, which can be a single string or a list of strings.
+type stringList []string
+
+// UnmarshalJSON takes b and unmarshals a string or a list of strings.
+// Returns error.
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*l = stringList{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
+
+// trustProblem takes a and document and returns why Cognito can't assume the role with the
+// trust policy document, empty string is returned if it can. IAM returns the document URL encoded
+// but leaves + as is, so it's path unescaped to not turn + into spaces.
+// Returns string.
+func trustProblem(a *AnalyticsConfigurationType, document string) string {
+	decoded, err := url.PathUnescape(document)
+	if err != nil {
+		decoded = document
+	}
+
+	policy := &trustPolicy{}
+	if err := json.Unmarshal([]byte(decoded), policy); err != nil {
+		return fmt.Sprintf("AnalyticsConfiguration.RoleArn %s has a trust policy that can't be read", a.RoleArn)
+	}
+
+	for _, st := range policy.Statement {
+		if st.Effect != "Allow" || !(st.Principal.Any || contains(st.Principal.Service, cognitoPrincipal)) {
+			continue
+		}
+		if !contains(st.Action, "sts:AssumeRole") && !contains(st.Action, "sts:*") && !contains(st.Action, "*") {
+			continue
+		}
+
+		// An ExternalId condition needs to match the ExternalId that Cognito sends.
+		for _, values := range st.Condition {
+			if ids, ok := values["sts:ExternalId"]; ok && !contains(ids, a.ExternalID) {
+				return fmt.Sprintf("AnalyticsConfiguration.RoleArn %s only trusts %s with ExternalId %s", a.RoleArn, cognitoPrincipal, strings.Join(ids, ", "))
+			}
+		}
+		return ""
+	}
+
+	return fmt.Sprintf("AnalyticsConfiguration.RoleArn %s doesn't trust %s to assume it", a.RoleArn, cognitoPrincipal)
+}
@@ -0,0 +1,182 @@
+package userpoolclient
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+const (
+	testApplicationArn = "arn:aws:mobiletargeting:eu-central-1:123456789012:apps/0123456789abcdef0123456789abcdef"
+	testRoleArn        = "arn:aws:iam::123456789012:role/service/cognito-analytics"
+)
+
+// Test that the analytics configuration is validated without calling AWS.
+func TestAnalyticsValidate(t *testing.T) {
+	tests := []struct {
+		analytics *AnalyticsConfigurationType
+		path      string // The property that the error is about, empty if there is no error.
+	}{
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn}},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, RoleArn: testRoleArn, UserDataShared: "true"}},
+		{analytics: &AnalyticsConfigurationType{ApplicationID: "abc", RoleArn: testRoleArn, ExternalID: "ext"}},
+		{analytics: &AnalyticsConfigurationType{}, path: "AnalyticsConfiguration"},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, ApplicationID: "abc"}, path: "AnalyticsConfiguration.ApplicationId"},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: "arn:aws:mobiletargeting:eu-central-1:123456789012:apps/abc/events"}, path: "AnalyticsConfiguration.ApplicationArn"},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: "0123456789abcdef0123456789abcdef"}, path: "AnalyticsConfiguration.ApplicationArn"},
+		{analytics: &AnalyticsConfigurationType{ApplicationID: "abc", RoleArn: testRoleArn}, path: "AnalyticsConfiguration.RoleArn"},
+		{analytics: &AnalyticsConfigurationType{ApplicationID: "abc", ExternalID: "ext"}, path: "AnalyticsConfiguration.RoleArn"},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, RoleArn: "cognito-analytics"}, path: "AnalyticsConfiguration.RoleArn"},
+		{analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, UserDataShared: "yes"}, path: "AnalyticsConfiguration.UserDataShared"},
+	}
+
+	for i, test := range tests {
+		err := test.analytics.validate()
+		switch {
+		case test.path == "" && err != nil:
+			t.Errorf("Test number: %d failed. Unexpected error %s", i+1, err.Error())
+
+		case test.path != "" && err == nil:
+			t.Errorf("Test number: %d failed. Expected an error for %s", i+1, test.path)
+
+		case test.path != "" && spec.ErrorPath(err) != test.path:
+			t.Errorf("Test number: %d failed. Wanted error for %s but got %s", i+1, test.path, err.Error())
+		}
+	}
+}
+
+// Test that the trust policy is checked to let Cognito assume the role with the ExternalId.
+func TestTrustProblem(t *testing.T) {
+	a := &AnalyticsConfigurationType{RoleArn: testRoleArn, ExternalID: "ext"}
+
+	tests := []struct {
+		document string
+		want     string
+	}{
+		{
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+		},
+		// A single statement, lists of services and actions, and a matching ExternalId.
+		{
+			document: `{"Statement":{"Effect":"Allow","Principal":{"Service":["lambda.amazonaws.com","cognito-idp.amazonaws.com"]},"Action":["sts:AssumeRole","sts:TagSession"],"Condition":{"StringEquals":{"sts:ExternalId":["other","ext"]}}}}`,
+		},
+		// IAM returns the document URL encoded.
+		{
+			document: url.PathEscape(`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"sts:*"}]}`),
+		},
+		// A + in the document is kept as is and isn't a space.
+		{
+			document: url.PathEscape(`{"Statement":[{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"sts:ExternalId":"ext+1"}}}]}`),
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " only trusts cognito-idp.amazonaws.com with ExternalId ext+1",
+		},
+		{
+			document: `{"Statement":[{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"sts:ExternalId":"other"}}}]}`,
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " only trusts cognito-idp.amazonaws.com with ExternalId other",
+		},
+		{
+			document: `{"Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " doesn't trust cognito-idp.amazonaws.com to assume it",
+		},
+		{
+			document: `{"Statement":[{"Effect":"Deny","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " doesn't trust cognito-idp.amazonaws.com to assume it",
+		},
+		{
+			document: `{"Statement":[{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:TagSession"}]}`,
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " doesn't trust cognito-idp.amazonaws.com to assume it",
+		},
+		{
+			document: `not a policy`,
+			want:     "AnalyticsConfiguration.RoleArn " + testRoleArn + " has a trust policy that can't be read",
+		},
+	}
+
+	for i, test := range tests {
+		if got := trustProblem(a, test.document); got != test.want {
+			t.Errorf("Test number: %d failed. Wanted %q but got %q", i+1, test.want, got)
+		}
+	}
+}
+
+// Test that the role is looked up and that every action is simulated on the resource of the Pinpoint app it's used on.
+func TestAnalyticsProblems(t *testing.T) {
+	trust := url.QueryEscape(`{"Statement":[{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+
+	tests := []struct {
+		analytics *AnalyticsConfigurationType
+		arn       string // The ARN of the role that IAM returns.
+		decisions []string
+		resources []string // The ResourceArns that the actions were simulated on.
+		want      []string
+	}{
+		{
+			analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, RoleArn: testRoleArn},
+			arn:       testRoleArn,
+			decisions: []string{"allowed", "allowed"},
+			resources: []string{testApplicationArn + "/events", testApplicationArn + "/endpoints/*"},
+			want:      []string{},
+		},
+		{
+			analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, RoleArn: testRoleArn},
+			arn:       testRoleArn,
+			decisions: []string{"allowed", "implicitDeny"},
+			resources: []string{testApplicationArn + "/events", testApplicationArn + "/endpoints/*"},
+			want:      []string{"AnalyticsConfiguration.RoleArn " + testRoleArn + " isn't allowed mobiletargeting:UpdateEndpoint (implicitDeny)"},
+		},
+		// Only the ID of the app is known, so the actions are simulated on every resource.
+		{
+			analytics: &AnalyticsConfigurationType{ApplicationID: "abc", RoleArn: testRoleArn, ExternalID: "ext"},
+			arn:       testRoleArn,
+			decisions: []string{"explicitDeny", "allowed"},
+			resources: []string{"", ""},
+			want:      []string{"AnalyticsConfiguration.RoleArn " + testRoleArn + " isn't allowed mobiletargeting:PutEvents (explicitDeny)"},
+		},
+		// A role with the same name in another path isn't the role.
+		{
+			analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn, RoleArn: testRoleArn},
+			arn:       "arn:aws:iam::123456789012:role/cognito-analytics",
+			want:      []string{"AnalyticsConfiguration.RoleArn " + testRoleArn + " doesn't exist in the account of the UserPool"},
+		},
+		// Without RoleArn Cognito uses its service-linked role.
+		{
+			analytics: &AnalyticsConfigurationType{ApplicationArn: testApplicationArn},
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("GetRole", "<Role><RoleName>cognito-analytics</RoleName><Arn>"+test.arn+"</Arn><AssumeRolePolicyDocument>"+trust+"</AssumeRolePolicyDocument></Role>")
+
+		bodies := []string{}
+		for _, decision := range test.decisions {
+			bodies = append(bodies, "<EvaluationResults><member><EvalDecision>"+decision+"</EvalDecision></member></EvaluationResults>")
+		}
+		if len(bodies) > 0 {
+			kit.RespondInOrder("SimulatePrincipalPolicy", bodies...)
+		}
+
+		got, err := testConfig(t).analyticsProblems(context.Background(), &Client{AnalyticsConfiguration: test.analytics})
+		if err != nil {
+			t.Errorf("Test number: %d failed. Unexpected error %s", i+1, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Test number: %d failed. Wanted %q but got %q", i+1, test.want, got)
+		}
+
+		resources := []string{}
+		for _, call := range kit.CallsTo("SimulatePrincipalPolicy") {
+			resources = append(resources, call.Params.Get("ResourceArns.member.1"))
+		}
+		if test.resources == nil {
+			test.resources = []string{}
+		}
+		if !reflect.DeepEqual(resources, test.resources) {
+			t.Errorf("Test number: %d failed. Wanted simulations on %q but got %q", i+1, test.resources, resources)
+		}
+	}
+}
@@ -1,25 +1,26 @@
-package main
+package userpoolclient
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 )
 
 // createClient will create a new client on the user pool with settings specified by req.
 // Returns map of string that is data that Fn::GetAtt can use.
 // Returns map[string]string error.
-func (c *config) createClient(req *events.Request) (map[string]string, error) {
+func (c *config) createClient(ctx context.Context, req *events.Request) (map[string]string, error) {
 
 	// Simple validation that will result in error.
-	switch {
-	case c.resourceProperties.ClientName == "":
-		return nil, fmt.Errorf("ClientName can't be empty")
-
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("UserPoolId can't be empty")
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
+	if err := c.preflight(ctx, c.resourceProperties); err != nil {
+		return nil, err
 	}
 
 	// Set generate secrets
@@ -77,36 +78,14 @@ func (c *config) createClient(req *events.Request) (map[string]string, error) {
 		input.SupportedIdentityProviders = c.resourceProperties.SupportedIdentityProviders
 	}
 
-	// Set Analytics
-	if c.resourceProperties.AnalyticsConfiguration != nil {
-		input.AnalyticsConfiguration = &cognitoidentityprovider.AnalyticsConfigurationType{UserDataShared: &c.resourceProperties.AnalyticsConfiguration.UserDataShared}
-
-		if c.resourceProperties.AnalyticsConfiguration.ApplicationID != "" {
-			input.AnalyticsConfiguration.ApplicationId = &c.resourceProperties.AnalyticsConfiguration.ApplicationID
-		}
-		if c.resourceProperties.AnalyticsConfiguration.ExternalID != "" {
-			input.AnalyticsConfiguration.ExternalId = &c.resourceProperties.AnalyticsConfiguration.ExternalID
-		}
-		if c.resourceProperties.AnalyticsConfiguration.RoleArn != "" {
-			input.AnalyticsConfiguration.RoleArn = &c.resourceProperties.AnalyticsConfiguration.RoleArn
-		}
-	}
-
-	resp, err := c.svc.CreateUserPoolClientRequest(input).Send()
+	r := c.svc.CreateUserPoolClientRequest(input)
+	r.SetContext(ctx)
+	awsclient.WithParams(r.Request, c.resourceProperties.settings())
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create Client. Error %s", err.Error())
-	}
-
-	attr := map[string]string{
-		"ClientName": *resp.UserPoolClient.ClientName,
-		"ClientId":   *resp.UserPoolClient.ClientId,
-		"UserPoolId": *resp.UserPoolClient.UserPoolId,
-	}
-
-	// Only set ClientSecret if it's set.
-	if resp.UserPoolClient.ClientSecret != nil {
-		attr["ClientSecret"] = *resp.UserPoolClient.ClientSecret
+		return nil, fmt.Errorf("Failed to create Client. Error %w", err)
 	}
+	c.trackClient(req, *resp.UserPoolClient.ClientId)
 
-	return attr, nil
+	return c.clientData(ctx, *resp.UserPoolClient.ClientId)
 }
@@ -0,0 +1,161 @@
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// clientAttributes is the state of the client that can be accessed with Fn::GetAtt.
+// Lists are joined with commas and every attribute is always set, so that Fn::GetAtt
+// never fails on a setting that happens to be empty.
+type clientAttributes struct {
+	ClientName   string `json:"ClientName"`
+	ClientID     string `json:"ClientId"`
+	UserPoolID   string `json:"UserPoolId"`
+	ClientSecret string `json:"ClientSecret,omitempty"`
+	SecretArn    string `json:"SecretArn,omitempty"`
+
+	GenerateSecret       string   `json:"GenerateSecret"`
+	RefreshTokenValidity string   `json:"RefreshTokenValidity"`
+	ReadAttributes       []string `json:"ReadAttributes"`
+	WriteAttributes      []string `json:"WriteAttributes"`
+	ExplicitAuthFlows    []string `json:"ExplicitAuthFlows"`
+
+	AllowedOAuthFlows               []string `json:"AllowedOAuthFlows"`
+	AllowedOAuthFlowsUserPoolClient string   `json:"AllowedOAuthFlowsUserPoolClient"`
+	AllowedOAuthScopes              []string `json:"AllowedOAuthScopes"`
+	CallbackURLs                    []string `json:"CallbackURLs"`
+	LogoutURLs                      []string `json:"LogoutURLs"`
+	DefaultRedirectURI              string   `json:"DefaultRedirectURI"`
+	SupportedIdentityProviders      []string `json:"SupportedIdentityProviders"`
+
+	AccessTokenValidity        string                 `json:"AccessTokenValidity"`
+	IDTokenValidity            string                 `json:"IdTokenValidity"`
+	TokenValidityUnits         TokenValidityUnitsType `json:"TokenValidityUnits"`
+	AuthSessionValidity        string                 `json:"AuthSessionValidity"`
+	PreventUserExistenceErrors string                 `json:"PreventUserExistenceErrors"`
+	EnableTokenRevocation      string                 `json:"EnableTokenRevocation"`
+
+	AnalyticsConfiguration analyticsAttributes `json:"AnalyticsConfiguration"`
+
+	CreationDate     string `json:"CreationDate"`
+	LastModifiedDate string `json:"LastModifiedDate"`
+
+	PreservedProperties []string `json:"PreservedProperties"`
+}
+
+// analyticsAttributes is the analytics configuration that Cognito uses for the client.
+type analyticsAttributes struct {
+	ApplicationArn string `json:"ApplicationArn"`
+	ApplicationID  string `json:"ApplicationId"`
+	ExternalID     string `json:"ExternalId"`
+	RoleArn        string `json:"RoleArn"`
+	UserDataShared string `json:"UserDataShared"`
+}
+
+// clientData takes the id of the created or updated client and reads the client back from
+// Cognito, so that the data that Fn::GetAtt can use is what Cognito actually has.
+// If SecretName is set the client is stored in the secret and only the ARN of the secret is
+// returned, so that ClientSecret never ends up in the stack.
+// Returns map[string]string and error.
+func (c *config) clientData(ctx context.Context, id string) (map[string]string, error) {
+	pc, err := c.describeClient(ctx, c.resourceProperties.UserPoolID, id)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("Failed to read back Client %s. Error %w", id, err)
+
+	case pc == nil:
+		return nil, fmt.Errorf("Failed to read back Client %s. It doesn't exist", id)
+	}
+
+	attr := toAttributes(pc)
+	attr.PreservedProperties = c.preserved
+
+	switch {
+	case c.resourceProperties.SecretName != "":
+		arn, err := c.storeClient(ctx, pc.UserPoolClientType)
+		if err != nil {
+			return nil, err
+		}
+		attr.SecretArn = arn
+
+	// Only set ClientSecret if it's set.
+	case pc.ClientSecret != nil:
+		attr.ClientSecret = *pc.ClientSecret
+	}
+
+	return events.Flatten(attr)
+}
+
+// toAttributes takes pc and returns its attributes, without ClientSecret.
+// Returns *clientAttributes.
+func toAttributes(pc *describedClient) *clientAttributes {
+	attr := &clientAttributes{
+		ClientName:                      aws.StringValue(pc.ClientName),
+		ClientID:                        aws.StringValue(pc.ClientId),
+		UserPoolID:                      aws.StringValue(pc.UserPoolId),
+		GenerateSecret:                  strconv.FormatBool(pc.ClientSecret != nil),
+		ReadAttributes:                  pc.ReadAttributes,
+		WriteAttributes:                 pc.WriteAttributes,
+		AllowedOAuthFlowsUserPoolClient: strconv.FormatBool(aws.BoolValue(pc.AllowedOAuthFlowsUserPoolClient)),
+		AllowedOAuthScopes:              pc.AllowedOAuthScopes,
+		CallbackURLs:                    pc.CallbackURLs,
+		LogoutURLs:                      pc.LogoutURLs,
+		DefaultRedirectURI:              aws.StringValue(pc.DefaultRedirectURI),
+		SupportedIdentityProviders:      pc.SupportedIdentityProviders,
+		AccessTokenValidity:             formatInt(pc.settings.AccessTokenValidity),
+		IDTokenValidity:                 formatInt(pc.settings.IDTokenValidity),
+		AuthSessionValidity:             formatInt(pc.settings.AuthSessionValidity),
+		PreventUserExistenceErrors:      pc.settings.PreventUserExistenceErrors,
+		EnableTokenRevocation:           strconv.FormatBool(aws.BoolValue(pc.settings.EnableTokenRevocation)),
+	}
+
+	// Cognito leaves out the units that are the default.
+	attr.TokenValidityUnits = TokenValidityUnitsType{AccessToken: "hours", IDToken: "hours", RefreshToken: "days"}
+	if u := pc.settings.TokenValidityUnits; u != nil {
+		if u.AccessToken != "" {
+			attr.TokenValidityUnits.AccessToken = u.AccessToken
+		}
+		if u.IDToken != "" {
+			attr.TokenValidityUnits.IDToken = u.IDToken
+		}
+		if u.RefreshToken != "" {
+			attr.TokenValidityUnits.RefreshToken = u.RefreshToken
+		}
+	}
+
+	attr.AnalyticsConfiguration.UserDataShared = "false"
+	if a := pc.settings.AnalyticsConfiguration; a != nil {
+		attr.AnalyticsConfiguration = analyticsAttributes{
+			ApplicationArn: a.ApplicationArn,
+			ApplicationID:  a.ApplicationID,
+			ExternalID:     a.ExternalID,
+			RoleArn:        a.RoleArn,
+			UserDataShared: strconv.FormatBool(aws.BoolValue(a.UserDataShared)),
+		}
+	}
+
+	for _, flow := range pc.ExplicitAuthFlows {
+		attr.ExplicitAuthFlows = append(attr.ExplicitAuthFlows, string(flow))
+	}
+	for _, flow := range pc.AllowedOAuthFlows {
+		attr.AllowedOAuthFlows = append(attr.AllowedOAuthFlows, string(flow))
+	}
+
+	if pc.RefreshTokenValidity != nil {
+		attr.RefreshTokenValidity = strconv.FormatInt(*pc.RefreshTokenValidity, 10)
+	}
+	if pc.CreationDate != nil {
+		attr.CreationDate = pc.CreationDate.UTC().Format(time.RFC3339)
+	}
+	if pc.LastModifiedDate != nil {
+		attr.LastModifiedDate = pc.LastModifiedDate.UTC().Format(time.RFC3339)
+	}
+
+	return attr
+}
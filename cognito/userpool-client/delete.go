@@ -1,15 +1,17 @@
-package main
+package userpoolclient
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 )
 
 // deleteClient will delete the UserPool Client specified with clientID.
 // Returns error.
-func (c *config) deleteClient(req *events.Request, id string) error {
+func (c *config) deleteClient(ctx context.Context, req *events.Request, id string) error {
 	// If resource creation fails. We will get an empty delete event.
 	// In these cases just return nil.
 	switch {
@@ -20,13 +22,22 @@ func (c *config) deleteClient(req *events.Request, id string) error {
 		return nil
 	}
 
-	_, err := c.svc.DeleteUserPoolClientRequest(
+	// Delete the secret first, so that it isn't left behind if deleting it fails and the delete is retried.
+	if err := c.deleteSecret(ctx, c.resourceProperties.SecretName, id); err != nil {
+		return err
+	}
+
+	r := c.svc.DeleteUserPoolClientRequest(
 		&cognitoidentityprovider.DeleteUserPoolClientInput{
 			ClientId:   &id,
 			UserPoolId: &c.resourceProperties.UserPoolID,
-		}).Send()
-	if err != nil {
-		return fmt.Errorf("Failed to delete Client. Error %s", err.Error())
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
+
+	// Clients that are tracked by ID might already have been deleted outside of CloudFormation.
+	if err != nil && !awserrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete Client. Error %w", err)
 	}
 
 	return nil
@@ -0,0 +1,58 @@
+package userpoolclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+)
+
+// Detect takes ctx, cfg, properties and physicalID and compares the declared Client against
+// the live client in Cognito. Like the handler the client is found by the ClientId in
+// physicalID, so a renamed client is still found. The client is only found by its name if
+// physicalID is unknown or a legacy physical ID.
+// Returns *drift.Result and error.
+func Detect(ctx context.Context, cfg aws.Config, properties json.RawMessage, physicalID string) (*drift.Result, error) {
+	c := &config{
+		svc:                awsclient.FromConfig(ctx, cfg, ResourceType).CognitoIdentityProvider(),
+		resourceProperties: &Client{},
+	}
+
+	if err := json.Unmarshal(properties, c.resourceProperties); err != nil {
+		return nil, fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+
+	client, err := c.detectClient(ctx, physicalID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+	}
+
+	if client == nil {
+		return drift.Deleted(), nil
+	}
+
+	// The secret and MergeMode aren't settings of the client, so they never drift.
+	client.SecretName, client.SecretKmsKeyID = c.resourceProperties.SecretName, c.resourceProperties.SecretKmsKeyID
+	client.SecretRotationID = c.resourceProperties.SecretRotationID
+	client.MergeMode = c.resourceProperties.MergeMode
+	return drift.Compare(c.resourceProperties, client), nil
+}
+
+// detectClient takes physicalID and returns the live client that it tracks. nil is returned
+// if the client doesn't exist, or was never created.
+// Returns *Client and error.
+func (c *config) detectClient(ctx context.Context, physicalID string) (*Client, error) {
+	id, ok := clientIDFromPhysicalID(physicalID)
+
+	switch {
+	case !ok:
+		return c.getClientByName(ctx, c.resourceProperties.UserPoolID, c.resourceProperties.ClientName, "")
+
+	case id == "":
+		return nil, nil
+	}
+	return c.getClient(ctx, c.resourceProperties.UserPoolID, id)
+}
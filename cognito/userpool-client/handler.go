@@ -0,0 +1,448 @@
+// Package userpoolclient handles the Custom::CognitoUserPoolClient resource.
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	l "github.com/nuttmeister/llogger"
+
+	// External - AWS
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// http client timeout in seconds.
+const (
+	service      = "custom-cf"
+	function     = "userpool-client"
+	ResourceType = "Custom::CognitoUserPoolClient"
+	httpTimeout  = 30
+)
+
+type config struct {
+	log     *l.Client
+	svc     *cognitoidentityprovider.CognitoIdentityProvider
+	secrets *secretsmanager.SecretsManager
+	iam     *iam.IAM
+
+	physicalID            string  // The physical ID to use for the resource.
+	resourceProperties    *Client // The new resource data from the template.
+	oldResourceProperties *Client // The old resource data, only on updates.
+
+	rotating  bool     // True if a new Client is created to rotate the secret.
+	preserved []string // The settings that MergeMode kept from the live Client.
+}
+
+// Client contains the data for the UserPool Client Settings.
+type Client struct {
+	id string
+
+	// Standard features.
+	ClientName           string                                          `json:"ClientName" cfn:"required" doc:"The name of the Client. This is required by this implementation (but not in regular API!)"`
+	UserPoolID           string                                          `json:"UserPoolId" cfn:"required,createOnly" doc:"The ID of the UserPool to create the Client in"`
+	GenerateSecret       string                                          `json:"GenerateSecret,omitempty" cfn:"createOnly,type=Boolean" doc:"If we should generate secret. If you adopt a resource, make sure this setting is correct. Since changing this requires replacement on the client. Defaults to false"`
+	RefreshTokenValidity string                                          `json:"RefreshTokenValidity,omitempty" cfn:"type=Integer" doc:"Refresh token validity in TokenValidityUnits.RefreshToken. Between 60 minutes and 10 years, defaults to 30 days"`
+	ReadAttributes       []string                                        `json:"ReadAttributes,omitempty" doc:"Read Attributes"`
+	WriteAttributes      []string                                        `json:"WriteAttributes,omitempty" doc:"Write Attributes"`
+	ExplicitAuthFlows    []cognitoidentityprovider.ExplicitAuthFlowsType `json:"ExplicitAuthFlows,omitempty" cfn:"enum=ALLOW_USER_SRP_AUTH|ALLOW_REFRESH_TOKEN_AUTH|ALLOW_USER_PASSWORD_AUTH|ALLOW_CUSTOM_AUTH|ALLOW_ADMIN_USER_PASSWORD_AUTH|ADMIN_NO_SRP_AUTH|CUSTOM_AUTH_FLOW_ONLY|USER_PASSWORD_AUTH" doc:"Explicit Auth Flows. The ALLOW_ values can't be mixed with the legacy values"`
+
+	// Extended features.
+	AllowedOAuthFlows               []cognitoidentityprovider.OAuthFlowType `json:"AllowedOAuthFlows,omitempty" cfn:"enum=code|implicit|client_credentials" doc:"Allowed OAuth Flows"`
+	AllowedOAuthFlowsUserPoolClient string                                  `json:"AllowedOAuthFlowsUserPoolClient,omitempty" cfn:"type=Boolean" doc:"Allowed OAuth Flows UserPool Client"`
+	AllowedOAuthScopes              []string                                `json:"AllowedOAuthScopes,omitempty" doc:"Allowed OAuth Scopes"`
+
+	CallbackURLs       []string `json:"CallbackURLs,omitempty" doc:"Callback URLs"`
+	LogoutURLs         []string `json:"LogoutURLs,omitempty" doc:"Logout URLs"`
+	DefaultRedirectURI string   `json:"DefaultRedirectURI,omitempty" doc:"Default Redirect URI"`
+
+	SupportedIdentityProviders []string `json:"SupportedIdentityProviders,omitempty" doc:"Name of supported providers (ProviderName). For current UserPool add **COGNITO**"`
+
+	AnalyticsConfiguration *AnalyticsConfigurationType `json:"AnalyticsConfiguration,omitempty" doc:"Analytics Configuration"`
+
+	// Token validities and security settings.
+	AccessTokenValidity        string                  `json:"AccessTokenValidity,omitempty" cfn:"type=Integer" doc:"Access token validity in TokenValidityUnits.AccessToken. Between 5 minutes and 1 day, defaults to 1 hour"`
+	IDTokenValidity            string                  `json:"IdTokenValidity,omitempty" cfn:"type=Integer" doc:"ID token validity in TokenValidityUnits.IdToken. Between 5 minutes and 1 day, defaults to 1 hour"`
+	TokenValidityUnits         *TokenValidityUnitsType `json:"TokenValidityUnits,omitempty" doc:"The units of AccessTokenValidity, IdTokenValidity and RefreshTokenValidity"`
+	AuthSessionValidity        string                  `json:"AuthSessionValidity,omitempty" cfn:"type=Integer" doc:"Validity of the session token of an authentication flow in minutes. Between 3 and 15, defaults to 3"`
+	PreventUserExistenceErrors string                  `json:"PreventUserExistenceErrors,omitempty" cfn:"enum=ENABLED|LEGACY" doc:"ENABLED hides if a user exists in the errors of authentication, confirmation and password recovery"`
+	EnableTokenRevocation      string                  `json:"EnableTokenRevocation,omitempty" cfn:"type=Boolean" doc:"If refresh tokens can be revoked. Defaults to true for new Clients"`
+
+	// Secrets Manager, these aren't settings of the client itself.
+	SecretName     string `json:"SecretName,omitempty" doc:"Name of a Secrets Manager secret to store the ClientId and ClientSecret in as JSON. The secret is created, updated and deleted with the Client and only its ARN is returned as SecretArn, instead of ClientSecret. Requires GenerateSecret to be true"`
+	SecretKmsKeyID string `json:"SecretKmsKeyId,omitempty" doc:"ID or ARN of the KMS key to encrypt the secret with. Defaults to the aws/secretsmanager key"`
+
+	SecretRotationID string `json:"SecretRotationId,omitempty" cfn:"createOnly" doc:"Changing this rotates the ClientSecret by creating a new Client, the old Client is kept until CloudFormation deletes it at the end of the stack update. Requires GenerateSecret to be true"`
+
+	// How Updates are done, this isn't a setting of the client.
+	MergeMode string `json:"MergeMode,omitempty" cfn:"type=Boolean" doc:"If true an Update keeps the settings of the Client that aren't in the template, instead of resetting them to their defaults. The kept settings are returned as PreservedProperties. Defaults to false"`
+}
+
+// AnalyticsConfigurationType contains config for Analytics on the Client.
+type AnalyticsConfigurationType struct {
+	ApplicationArn string `json:"ApplicationArn,omitempty" doc:"ARN of the Pinpoint app, which can be in another region than the UserPool. Use instead of ApplicationId, RoleArn and ExternalId are then optional"`
+	ApplicationID  string `json:"ApplicationId,omitempty" doc:"ID of the Pinpoint app in the region of the UserPool, requires RoleArn and ExternalId"`
+	ExternalID     string `json:"ExternalId,omitempty" doc:"External ID that Cognito uses when it assumes RoleArn"`
+	RoleArn        string `json:"RoleArn,omitempty" doc:"ARN of the role that Cognito sends events to Pinpoint with. It's checked to trust cognito-idp.amazonaws.com and to allow mobiletargeting:PutEvents and mobiletargeting:UpdateEndpoint"`
+	UserDataShared string `json:"UserDataShared,omitempty" cfn:"type=Boolean" doc:"If user data is shared with Pinpoint. Defaults to false"`
+}
+
+// Handler takes context.Context and *events.Request.
+// Returns error.
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
+	// Create the config.
+	c := createConfig(ctx, req)
+	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
+	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
+
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Create the Cognit service.
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
+	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Set physical ID - clients are tracked by ID, which is set once the client is known.
+	// Until then a physical ID without a client ID is used, so that a failed Create is never
+	// mistaken for a client that was created before clients were tracked by ID.
+	c.physicalID = req.PhysicalResourceID
+	if req.RequestType == "Create" {
+		c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s/", c.resourceProperties.UserPoolID))
+	}
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
+
+	// create, update or delete the userpool client.
+	data, err := c.run(awsCtx, req)
+	if err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Send the result to the pre-signed s3 url.
+	if err := req.SendWithContext(ctx, c.physicalID, data, err); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
+// Returns error.
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
+	if err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
+			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		}
+	}
+	return err
+}
+
+// createConfig takes ctx and req and creates a config that contains the logger
+// and the cognito aws service.
+// Returns *config and error.
+func createConfig(ctx context.Context, req *events.Request) *config {
+	return &config{
+		log: l.Create(ctx, l.Input{
+			"service":               service,
+			"function":              function,
+			"env":                   os.Getenv("ENVIRONMENT"),
+			"stackId":               req.StackID,
+			"requestType":           req.RequestType,
+			"requestId":             req.RequestID,
+			"resourceType":          req.ResourceType,
+			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
+			"resourceProperties":    req.ResourceProperties,
+			"oldResourceProperties": req.OldResourceProperties,
+		}),
+		physicalID:            "NotAviable",
+		resourceProperties:    &Client{},
+		oldResourceProperties: &Client{},
+	}
+}
+
+// Creates the CognitoIdentity, Secrets Manager and IAM Services for the target account and region in req.
+// Returns error.
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
+	if err != nil {
+		return err
+	}
+
+	c.svc = clients.CognitoIdentityProvider()
+	c.secrets = clients.SecretsManager()
+	c.iam = clients.IAM()
+	return nil
+}
+
+// run will either create, update or delete the specified userpool client.
+// Clients are tracked by the ClientId in the physical ID, so renaming a client updates it.
+// If a client with the name already exists in the user pool on Create it will be adopted
+// into the cf stack. This so that manually created clients don't have to be recreated.
+// Returns map[string]string and error.
+func (c *config) run(ctx context.Context, req *events.Request) (map[string]string, error) {
+	// Check for the correct ResourceType
+	if req.ResourceType != ResourceType {
+		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
+	}
+
+	id, ok := clientIDFromPhysicalID(req.PhysicalResourceID)
+
+	switch {
+	// Clients created before they were tracked by ID have their name in the physical ID.
+	case req.RequestType != "Create" && !ok:
+		return c.runLegacy(ctx, req)
+
+	// The client was never created if the physical ID doesn't contain its ID.
+	case req.RequestType == "Delete" && id == "":
+		return nil, nil
+
+	case req.RequestType == "Delete":
+		return nil, c.deleteClient(ctx, req, id)
+
+	// Replace the client if the secret is rotated or a setting that requires a new client is changed.
+	// CloudFormation deletes the old client once the stack update is done.
+	case req.RequestType == "Update" && (id == "" || c.replacesClient()):
+		c.rotating = c.oldResourceProperties.SecretRotationID != c.resourceProperties.SecretRotationID
+		return c.createClient(ctx, req)
+
+	case req.RequestType == "Update":
+		client, err := c.getClient(ctx, c.resourceProperties.UserPoolID, id)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+
+		// Recreate the client if it was deleted outside of CloudFormation.
+		case client == nil:
+			return c.createClient(ctx, req)
+		}
+		return c.updateClient(ctx, req, id)
+
+	case req.RequestType == "Create":
+		client, err := c.getClientByName(ctx, c.resourceProperties.UserPoolID, c.resourceProperties.ClientName, "")
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+
+		// If the Client exists, adopt and update it.
+		case client != nil:
+			return c.updateClient(ctx, req, client.id)
+		}
+		return c.createClient(ctx, req)
+	}
+
+	return nil, fmt.Errorf("Didn't get RequestType Create, Update or Delete")
+}
+
+// getClientByName will get the userpool client with clientName on User Pool
+// with poolID, leaving out the client with ID exclude. If nil is returned no client
+// by that name was found. It's an error if more than one client has the name, since
+// there is no way to tell which one is meant.
+// Return *Client and error.
+func (c *config) getClientByName(ctx context.Context, poolID string, clientName string, exclude string) (*Client, error) {
+	// Just return nil, nil if any of the required fields are missing.
+	// Extra validation will be done in the specific resource creation
+	// functions. This is so that Delete on empty will not fail.
+	switch {
+	case poolID == "":
+		return nil, nil
+
+	case clientName == "":
+		return nil, nil
+	}
+
+	// Since we need the Client ID to do any changes we first need to list
+	// all clients and see if any matches our name.
+	list, err := c.getClientsFromUserPool(ctx, poolID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Loop over list of clients to match our clientName.
+	ids := []string{}
+	for _, client := range list {
+		if *client.ClientName == clientName && *client.ClientId != exclude {
+			ids = append(ids, *client.ClientId)
+		}
+	}
+
+	switch {
+	case len(ids) == 0:
+		return nil, nil
+
+	case len(ids) > 1:
+		return nil, fmt.Errorf("Found %d Clients named %s in UserPool %s (%s), can't tell which one is meant. Rename or delete the others", len(ids), clientName, poolID, strings.Join(ids, ", "))
+	}
+
+	return c.getClient(ctx, poolID, ids[0])
+}
+
+// getClient will get the userpool client with id on User Pool with poolID.
+// If nil is returned the client doesn't exist.
+// Returns *Client and error.
+func (c *config) getClient(ctx context.Context, poolID string, id string) (*Client, error) {
+	pc, err := c.describeClient(ctx, poolID, id)
+	if err != nil || pc == nil {
+		return nil, err
+	}
+
+	return c.responseToClient(pc, id)
+}
+
+// describedClient is a described client together with the settings that the AWS SDK doesn't know.
+type describedClient struct {
+	*cognitoidentityprovider.UserPoolClientType
+	settings clientSettings
+}
+
+// describeClient will describe the userpool client with id on User Pool with poolID.
+// If nil is returned the client doesn't exist.
+// Returns *describedClient and error.
+func (c *config) describeClient(ctx context.Context, poolID string, id string) (*describedClient, error) {
+	result := &struct{ UserPoolClient clientSettings }{}
+
+	r := c.svc.DescribeUserPoolClientRequest(
+		&cognitoidentityprovider.DescribeUserPoolClientInput{
+			UserPoolId: &poolID,
+			ClientId:   &id,
+		})
+	r.SetContext(ctx)
+	awsclient.WithResult(r.Request, result)
+	resp, err := r.Send()
+	if err != nil {
+		// If the Client doesn't exists. Return nil, nil.
+		if awserrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &describedClient{UserPoolClientType: resp.UserPoolClient, settings: result.UserPoolClient}, nil
+}
+
+// getClientsFromUserPool takes poolID, clients and nextToken and retrieves all clients on
+// the userpool with UserPoolID poolID. This function is recursive so it will
+// execute it self if there is a nextToken. Leave nextToken as nil if it's the first run.
+// Returns []cognitoidentityprovider.UserPoolClientDescription and error.
+func (c *config) getClientsFromUserPool(ctx context.Context, poolID string, clients []cognitoidentityprovider.UserPoolClientDescription, nextToken *string) ([]cognitoidentityprovider.UserPoolClientDescription, error) {
+	// If clients is nil, create it.
+	if clients == nil {
+		clients = []cognitoidentityprovider.UserPoolClientDescription{}
+	}
+
+	// Create the Input and set nextToken if it's set.
+	input := &cognitoidentityprovider.ListUserPoolClientsInput{UserPoolId: &poolID}
+	if nextToken != nil {
+		input.NextToken = nextToken
+	}
+
+	// Get the clients for the userpool.
+	r := c.svc.ListUserPoolClientsRequest(input)
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return clients, fmt.Errorf("Couldn't get Clients for UserPool ID: %s. Error %w", poolID, err)
+	}
+
+	// Append clients.
+	clients = append(clients, resp.UserPoolClients...)
+
+	// If responses nextToken isn't nil, run recursive function.
+	if resp.NextToken != nil {
+		return c.getClientsFromUserPool(ctx, poolID, clients, resp.NextToken)
+	}
+
+	return clients, nil
+}
+
+// responseToClient takes pc and id and converts it to Client struct and returns it.
+// Returns *Client and error.
+func (c *config) responseToClient(pc *describedClient, id string) (*Client, error) {
+	// Simple validation that will result in error.
+	switch {
+	case pc.ClientName == nil:
+		return nil, fmt.Errorf("ClientName can't be empty")
+
+	case pc.UserPoolId == nil:
+		return nil, fmt.Errorf("UserPoolId can't be empty")
+	}
+
+	client := &Client{
+		id:                         id,
+		ClientName:                 *pc.ClientName,
+		UserPoolID:                 *pc.UserPoolId,
+		GenerateSecret:             strconv.FormatBool(pc.ClientSecret != nil),
+		ReadAttributes:             pc.ReadAttributes,
+		WriteAttributes:            pc.WriteAttributes,
+		ExplicitAuthFlows:          pc.ExplicitAuthFlows,
+		AllowedOAuthFlows:          pc.AllowedOAuthFlows,
+		AllowedOAuthScopes:         pc.AllowedOAuthScopes,
+		CallbackURLs:               pc.CallbackURLs,
+		LogoutURLs:                 pc.LogoutURLs,
+		SupportedIdentityProviders: pc.SupportedIdentityProviders,
+	}
+
+	// Set optional settings.
+	if pc.RefreshTokenValidity != nil {
+		client.RefreshTokenValidity = strconv.FormatInt(*pc.RefreshTokenValidity, 10)
+	}
+	if pc.AllowedOAuthFlowsUserPoolClient != nil {
+		client.AllowedOAuthFlowsUserPoolClient = strconv.FormatBool(*pc.AllowedOAuthFlowsUserPoolClient)
+	}
+	if pc.DefaultRedirectURI != nil {
+		client.DefaultRedirectURI = *pc.DefaultRedirectURI
+	}
+
+	// Set the settings that the AWS SDK doesn't know.
+	client.AccessTokenValidity = formatInt(pc.settings.AccessTokenValidity)
+	client.IDTokenValidity = formatInt(pc.settings.IDTokenValidity)
+	client.AuthSessionValidity = formatInt(pc.settings.AuthSessionValidity)
+	client.TokenValidityUnits = pc.settings.TokenValidityUnits
+	client.PreventUserExistenceErrors = pc.settings.PreventUserExistenceErrors
+	if pc.settings.EnableTokenRevocation != nil {
+		client.EnableTokenRevocation = strconv.FormatBool(*pc.settings.EnableTokenRevocation)
+	}
+
+	// Set Analytics
+	if a := pc.settings.AnalyticsConfiguration; a != nil {
+		client.AnalyticsConfiguration = &AnalyticsConfigurationType{
+			ApplicationArn: a.ApplicationArn,
+			ApplicationID:  a.ApplicationID,
+			ExternalID:     a.ExternalID,
+			RoleArn:        a.RoleArn,
+			UserDataShared: strconv.FormatBool(a.UserDataShared != nil && *a.UserDataShared),
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	userpoolclient "github.com/dwtechnologies/custom-cf/cognito/userpool-client"
+)
+
+func main() {
+	lambda.Start(userpoolclient.Handler)
+}
@@ -0,0 +1,127 @@
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	l "github.com/nuttmeister/llogger"
+)
+
+// merger overlays the declared settings of a client on the live client and keeps
+// track of the settings that were kept from the live client.
+type merger struct {
+	preserved []string
+}
+
+// mergeClient takes id and returns the resource properties with the settings that aren't
+// declared taken from the live client with id, since Cognito resets every setting that an
+// Update leaves out. The names of the preserved settings are logged and kept in c.preserved.
+// Returns *Client and error.
+func (c *config) mergeClient(ctx context.Context, id string) (*Client, error) {
+	live, err := c.getClient(ctx, c.resourceProperties.UserPoolID, id)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("Failed to read Client %s to merge with. Error %w", id, err)
+
+	case live == nil:
+		return nil, fmt.Errorf("Failed to read Client %s to merge with. It doesn't exist", id)
+	}
+
+	merged, preserved := c.resourceProperties.merge(live)
+	c.preserved = preserved
+
+	if len(preserved) > 0 {
+		c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Preserved settings of Client %s that aren't in the template: %s", id, strings.Join(preserved, ", "))})
+	}
+	return merged, nil
+}
+
+// merge takes live and returns a copy of cl where every setting that isn't declared is
+// taken from live, together with the names of those settings. Lists are declared as soon
+// as they are set, so an empty list still clears the setting.
+// Returns *Client and []string.
+func (cl *Client) merge(live *Client) (*Client, []string) {
+	merged := *cl
+	m := &merger{preserved: []string{}}
+
+	m.list("ReadAttributes", &merged.ReadAttributes, live.ReadAttributes)
+	m.list("WriteAttributes", &merged.WriteAttributes, live.WriteAttributes)
+	if merged.ExplicitAuthFlows == nil && len(live.ExplicitAuthFlows) > 0 {
+		merged.ExplicitAuthFlows = live.ExplicitAuthFlows
+		m.keep("ExplicitAuthFlows")
+	}
+
+	if merged.AllowedOAuthFlows == nil && len(live.AllowedOAuthFlows) > 0 {
+		merged.AllowedOAuthFlows = live.AllowedOAuthFlows
+		m.keep("AllowedOAuthFlows")
+	}
+	// false is what an Update without the setting sends anyway.
+	if merged.AllowedOAuthFlowsUserPoolClient == "" && live.AllowedOAuthFlowsUserPoolClient == "true" {
+		merged.AllowedOAuthFlowsUserPoolClient = live.AllowedOAuthFlowsUserPoolClient
+		m.keep("AllowedOAuthFlowsUserPoolClient")
+	}
+	m.list("AllowedOAuthScopes", &merged.AllowedOAuthScopes, live.AllowedOAuthScopes)
+	m.list("CallbackURLs", &merged.CallbackURLs, live.CallbackURLs)
+	m.list("LogoutURLs", &merged.LogoutURLs, live.LogoutURLs)
+	m.string("DefaultRedirectURI", &merged.DefaultRedirectURI, live.DefaultRedirectURI)
+	m.list("SupportedIdentityProviders", &merged.SupportedIdentityProviders, live.SupportedIdentityProviders)
+
+	if merged.AnalyticsConfiguration == nil && live.AnalyticsConfiguration != nil {
+		merged.AnalyticsConfiguration = live.AnalyticsConfiguration
+		m.keep("AnalyticsConfiguration")
+	}
+
+	// A validity is kept together with its unit, so that a declared unit never changes
+	// the meaning of a validity that is kept, and the other way around.
+	declaredUnits, liveUnits := TokenValidityUnitsType{}, TokenValidityUnitsType{}
+	if cl.TokenValidityUnits != nil {
+		declaredUnits = *cl.TokenValidityUnits
+	}
+	if live.TokenValidityUnits != nil {
+		liveUnits = *live.TokenValidityUnits
+	}
+	m.validity("AccessTokenValidity", &merged.AccessTokenValidity, &declaredUnits.AccessToken, live.AccessTokenValidity, liveUnits.AccessToken)
+	m.validity("IdTokenValidity", &merged.IDTokenValidity, &declaredUnits.IDToken, live.IDTokenValidity, liveUnits.IDToken)
+	m.validity("RefreshTokenValidity", &merged.RefreshTokenValidity, &declaredUnits.RefreshToken, live.RefreshTokenValidity, liveUnits.RefreshToken)
+	merged.TokenValidityUnits = nil
+	if declaredUnits != (TokenValidityUnitsType{}) {
+		merged.TokenValidityUnits = &declaredUnits
+	}
+
+	m.string("AuthSessionValidity", &merged.AuthSessionValidity, live.AuthSessionValidity)
+	m.string("PreventUserExistenceErrors", &merged.PreventUserExistenceErrors, live.PreventUserExistenceErrors)
+	m.string("EnableTokenRevocation", &merged.EnableTokenRevocation, live.EnableTokenRevocation)
+
+	return &merged, m.preserved
+}
+
+// keep takes name and adds it to the preserved settings.
+func (m *merger) keep(name string) {
+	m.preserved = append(m.preserved, name)
+}
+
+// string takes name, dst and live and sets dst to live if dst isn't declared.
+func (m *merger) string(name string, dst *string, live string) {
+	if *dst == "" && live != "" {
+		*dst = live
+		m.keep(name)
+	}
+}
+
+// list takes name, dst and live and sets dst to live if dst isn't declared.
+func (m *merger) list(name string, dst *[]string, live []string) {
+	if *dst == nil && len(live) > 0 {
+		*dst = live
+		m.keep(name)
+	}
+}
+
+// validity takes name, dst, unit, live and liveUnit and sets dst and unit to live and
+// liveUnit if dst isn't declared.
+func (m *merger) validity(name string, dst *string, unit *string, live string, liveUnit string) {
+	if *dst == "" && live != "" {
+		*dst, *unit = live, liveUnit
+		m.keep(name)
+	}
+}
@@ -0,0 +1,108 @@
+package userpoolclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test that only the settings that aren't declared are taken from the live client.
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		declared  *Client
+		live      *Client
+		want      *Client
+		preserved []string
+	}{
+		// Nothing to keep.
+		{
+			declared:  &Client{ClientName: "web", CallbackURLs: []string{"https://example.com"}},
+			live:      &Client{ClientName: "web"},
+			want:      &Client{ClientName: "web", CallbackURLs: []string{"https://example.com"}},
+			preserved: []string{},
+		},
+		// Settings that aren't declared are kept, in the order of the properties.
+		{
+			declared: &Client{ClientName: "web"},
+			live: &Client{
+				ClientName:                      "console",
+				ReadAttributes:                  []string{"email"},
+				AllowedOAuthFlowsUserPoolClient: "true",
+				CallbackURLs:                    []string{"https://example.com"},
+				DefaultRedirectURI:              "https://example.com",
+				AnalyticsConfiguration:          &AnalyticsConfigurationType{ApplicationID: "abc"},
+				PreventUserExistenceErrors:      "ENABLED",
+			},
+			want: &Client{
+				ClientName:                      "web",
+				ReadAttributes:                  []string{"email"},
+				AllowedOAuthFlowsUserPoolClient: "true",
+				CallbackURLs:                    []string{"https://example.com"},
+				DefaultRedirectURI:              "https://example.com",
+				AnalyticsConfiguration:          &AnalyticsConfigurationType{ApplicationID: "abc"},
+				PreventUserExistenceErrors:      "ENABLED",
+			},
+			preserved: []string{"ReadAttributes", "AllowedOAuthFlowsUserPoolClient", "CallbackURLs", "DefaultRedirectURI", "AnalyticsConfiguration", "PreventUserExistenceErrors"},
+		},
+		// An empty list is declared and clears the setting.
+		{
+			declared:  &Client{ClientName: "web", LogoutURLs: []string{}},
+			live:      &Client{ClientName: "web", LogoutURLs: []string{"https://example.com/logout"}},
+			want:      &Client{ClientName: "web", LogoutURLs: []string{}},
+			preserved: []string{},
+		},
+		// false is what an Update sends anyway, so it isn't kept.
+		{
+			declared:  &Client{ClientName: "web"},
+			live:      &Client{ClientName: "web", AllowedOAuthFlowsUserPoolClient: "false"},
+			want:      &Client{ClientName: "web"},
+			preserved: []string{},
+		},
+		// A validity is kept together with its unit, and the declared units are left as they are.
+		{
+			declared: &Client{ClientName: "web", IDTokenValidity: "2", TokenValidityUnits: &TokenValidityUnitsType{IDToken: "hours"}},
+			live: &Client{
+				ClientName:          "web",
+				AccessTokenValidity: "30",
+				IDTokenValidity:     "60",
+				TokenValidityUnits:  &TokenValidityUnitsType{AccessToken: "minutes", IDToken: "minutes"},
+			},
+			want: &Client{
+				ClientName:          "web",
+				AccessTokenValidity: "30",
+				IDTokenValidity:     "2",
+				TokenValidityUnits:  &TokenValidityUnitsType{AccessToken: "minutes", IDToken: "hours"},
+			},
+			preserved: []string{"AccessTokenValidity"},
+		},
+		// A declared unit doesn't change the meaning of a validity that isn't declared.
+		{
+			declared:  &Client{ClientName: "web", TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "hours"}},
+			live:      &Client{ClientName: "web", RefreshTokenValidity: "30", TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "days"}},
+			want:      &Client{ClientName: "web", RefreshTokenValidity: "30", TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "days"}},
+			preserved: []string{"RefreshTokenValidity"},
+		},
+		// No units at all stay nil.
+		{
+			declared:  &Client{ClientName: "web", AuthSessionValidity: "5"},
+			live:      &Client{ClientName: "web", AuthSessionValidity: "3"},
+			want:      &Client{ClientName: "web", AuthSessionValidity: "5"},
+			preserved: []string{},
+		},
+	}
+
+	for i, test := range tests {
+		before := *test.declared
+		got, preserved := test.declared.merge(test.live)
+
+		switch {
+		case !reflect.DeepEqual(got, test.want):
+			t.Errorf("Test number: %d failed. Wanted %+v but got %+v", i+1, test.want, got)
+
+		case !reflect.DeepEqual(preserved, test.preserved):
+			t.Errorf("Test number: %d failed. Wanted preserved %v but got %v", i+1, test.preserved, preserved)
+
+		case !reflect.DeepEqual(*test.declared, before):
+			t.Errorf("Test number: %d failed. The declared client was changed", i+1)
+		}
+	}
+}
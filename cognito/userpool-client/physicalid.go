@@ -0,0 +1,126 @@
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// physicalIDRegexp matches the physical IDs of clients that are tracked by ID, which are
+// <UserPoolId>/<ClientId> followed by the target account and region if set. The ClientId
+// is empty if the client was never created.
+var physicalIDRegexp = regexp.MustCompile(`^[\w-]*/(\w*)`)
+
+// replacesClient returns true if an Update changes a setting that needs a new client.
+// Returns bool.
+func (c *config) replacesClient() bool {
+	old, new := c.oldResourceProperties, c.resourceProperties
+
+	switch {
+	case old.SecretRotationID != new.SecretRotationID:
+		return true
+
+	case old.UserPoolID != new.UserPoolID, old.GenerateSecret != new.GenerateSecret:
+		return true
+	}
+	return false
+}
+
+// trackClient takes req and id and sets the physical ID to the client with id.
+// Call it as soon as the client exists, so that a failure after that still lets
+// CloudFormation delete the client.
+func (c *config) trackClient(req *events.Request, id string) {
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s/%s", c.resourceProperties.UserPoolID, id))
+}
+
+// clientIDFromPhysicalID takes physicalID and returns the client ID in it.
+// ok is false if physicalID is a legacy physical ID that contains the name of the client instead.
+// Returns string and bool.
+func clientIDFromPhysicalID(physicalID string) (string, bool) {
+	m := physicalIDRegexp.FindStringSubmatch(physicalID)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// runLegacy will update or delete a client with a legacy physical ID, which contains the
+// name of the client instead of its ID. The client is found by the name it had, and an
+// Update moves it over to a physical ID with its ID.
+//
+// CloudFormation deletes the legacy physical ID once the physical ID has changed, which
+// would find the same client by name. So the client that the stack now tracks by ID is
+// never deleted by a legacy Delete.
+// Returns map[string]string and error.
+func (c *config) runLegacy(ctx context.Context, req *events.Request) (map[string]string, error) {
+	props := c.resourceProperties
+	if req.RequestType == "Update" {
+		props = c.oldResourceProperties
+	}
+
+	exclude := ""
+	if req.RequestType == "Delete" {
+		current, err := c.currentPhysicalID(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		exclude, _ = clientIDFromPhysicalID(current)
+	}
+
+	client, err := c.getClientByName(ctx, props.UserPoolID, props.ClientName, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+	}
+
+	switch {
+	// If Delete is run on the resource but the Client doesn't exist.
+	case req.RequestType == "Delete" && client == nil:
+		return nil, nil
+
+	case req.RequestType == "Delete":
+		return nil, c.deleteClient(ctx, req, client.id)
+
+	// Create the client if it doesn't exist or needs to be replaced. The legacy
+	// physical ID is deleted by CloudFormation once the stack update is done.
+	case req.RequestType == "Update" && (client == nil || c.replacesClient()):
+		c.rotating = c.oldResourceProperties.SecretRotationID != c.resourceProperties.SecretRotationID
+		return c.createClient(ctx, req)
+
+	case req.RequestType == "Update":
+		return c.updateClient(ctx, req, client.id)
+	}
+
+	return nil, fmt.Errorf("Didn't get RequestType Update or Delete")
+}
+
+// currentPhysicalID takes req and returns the physical ID that the stack currently has for
+// the resource. It's the physical ID of req unless the resource has been given a new one.
+// Returns string and error.
+func (c *config) currentPhysicalID(ctx context.Context, req *events.Request) (string, error) {
+	clients, err := awsclient.NewDefault(ctx, ResourceType)
+	if err != nil {
+		return "", err
+	}
+
+	r := clients.CloudFormation().DescribeStackResourceRequest(&cloudformation.DescribeStackResourceInput{
+		StackName:         &req.StackID,
+		LogicalResourceId: &req.LogicalResourceID,
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return "", fmt.Errorf("Couldn't get the physical ID of %s in the stack. Error %w", req.LogicalResourceID, err)
+	}
+
+	id := aws.StringValue(resp.StackResourceDetail.PhysicalResourceId)
+	if id == "" {
+		return req.PhysicalResourceID, nil
+	}
+	return id, nil
+}
@@ -0,0 +1,137 @@
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+// legacyPhysicalID is the physical ID that clients had before they were tracked by ID.
+const legacyPhysicalID = testPoolID + "-%!t(string=false)-web"
+
+// Test that the client ID is found in every kind of physical ID.
+func TestClientIDFromPhysicalID(t *testing.T) {
+	tests := []struct {
+		physicalID string
+		id         string
+		ok         bool
+	}{
+		{physicalID: testPoolID + "/1example23456789", id: "1example23456789", ok: true},
+		{physicalID: testPoolID + "/1example23456789-123456789012-eu-west-1", id: "1example23456789", ok: true},
+		{physicalID: testPoolID + "/1example23456789-eu-north-1", id: "1example23456789", ok: true},
+		{physicalID: testPoolID + "/", id: "", ok: true},
+		{physicalID: testPoolID + "/-123456789012-eu-west-1", id: "", ok: true},
+		{physicalID: legacyPhysicalID, id: "", ok: false},
+		{physicalID: testPoolID + "-%!t(string=true)-web/app-123456789012", id: "", ok: false},
+		{physicalID: "", id: "", ok: false},
+	}
+
+	for i, test := range tests {
+		id, ok := clientIDFromPhysicalID(test.physicalID)
+		if id != test.id || ok != test.ok {
+			t.Errorf("Test number: %d failed. Wanted %q, %t but got %q, %t", i+1, test.id, test.ok, id, ok)
+		}
+	}
+}
+
+// legacyProperties are the properties of a client that still has a legacy physical ID.
+var legacyProperties = map[string]string{"ClientName": "web", "UserPoolId": testPoolID}
+
+// Test that an Update of a legacy physical ID finds the client by name and moves it to a physical ID with its ID.
+func TestLegacyUpdate(t *testing.T) {
+	kit := testkit.New(t)
+	kit.Respond("ListUserPoolClients", `{"UserPoolClients":[{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"},{"ClientId":"def","ClientName":"app","UserPoolId":"`+testPoolID+`"}]}`)
+	kit.Respond("UpdateUserPoolClient", `{"UserPoolClient":{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"}}`)
+	kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"}}`)
+
+	req := kit.Request(events.RequestUpdate, ResourceType, legacyProperties, legacyProperties)
+	req.PhysicalResourceID = legacyPhysicalID
+
+	resp := kit.Run(Handler, req)
+	switch {
+	case resp.Status != "SUCCESS":
+		t.Fatalf("Expected SUCCESS but got %s. Reason %s", resp.Status, resp.Reason)
+
+	case resp.PhysicalResourceID != testPoolID+"/abc":
+		t.Errorf("Expected physical ID %s/abc but got %s", testPoolID, resp.PhysicalResourceID)
+
+	case fmt.Sprint(kit.Calls()) != "[ListUserPoolClients DescribeUserPoolClient UpdateUserPoolClient DescribeUserPoolClient]":
+		t.Errorf("Unexpected calls %v", kit.Calls())
+	}
+}
+
+// Test that a Delete of a legacy physical ID never deletes the client that the stack now tracks by ID,
+// but still deletes the client if the resource is removed from the stack.
+func TestLegacyDelete(t *testing.T) {
+	tests := []struct {
+		current string
+		calls   string
+	}{
+		// The Delete of the legacy physical ID after an Update moved the client to a physical ID with its ID.
+		{current: testPoolID + "/abc", calls: "[DescribeStackResource ListUserPoolClients]"},
+		// The resource was removed from the stack and still has the legacy physical ID.
+		{current: legacyPhysicalID, calls: "[DescribeStackResource ListUserPoolClients DescribeUserPoolClient DeleteUserPoolClient]"},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("DescribeStackResource", "<StackResourceDetail><PhysicalResourceId>"+test.current+"</PhysicalResourceId></StackResourceDetail>")
+		kit.Respond("ListUserPoolClients", `{"UserPoolClients":[{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"}]}`)
+		kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"}}`)
+		kit.Respond("DeleteUserPoolClient", "")
+
+		req := kit.Request(events.RequestDelete, ResourceType, legacyProperties, nil)
+		req.PhysicalResourceID = legacyPhysicalID
+
+		resp := kit.Run(Handler, req)
+		switch {
+		case resp.Status != "SUCCESS":
+			t.Errorf("Test number: %d failed. Expected SUCCESS but got %s. Reason %s", i+1, resp.Status, resp.Reason)
+
+		case fmt.Sprint(kit.Calls()) != test.calls:
+			t.Errorf("Test number: %d failed. Wanted calls %s but got %v", i+1, test.calls, kit.Calls())
+		}
+	}
+}
+
+// Test that Detect finds the client by the ClientId in the physical ID, and by name without one.
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		physicalID string
+		calls      string
+		status     string
+	}{
+		{physicalID: testPoolID + "/abc-123456789012-eu-west-1", calls: "[DescribeUserPoolClient]", status: drift.StatusInSync},
+		{physicalID: testPoolID + "/", calls: "[]", status: drift.StatusDeleted},
+		{physicalID: "", calls: "[ListUserPoolClients DescribeUserPoolClient]", status: drift.StatusInSync},
+		{physicalID: legacyPhysicalID, calls: "[ListUserPoolClients DescribeUserPoolClient]", status: drift.StatusInSync},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("ListUserPoolClients", `{"UserPoolClients":[{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`"}]}`)
+		kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientId":"abc","ClientName":"web","UserPoolId":"`+testPoolID+`","RefreshTokenValidity":30}}`)
+
+		cfg, err := awsconfig.LoadDefault()
+		if err != nil {
+			t.Fatalf("Couldn't load AWS config. Error %s", err.Error())
+		}
+
+		result, err := Detect(context.Background(), cfg, []byte(`{"ClientName":"web","UserPoolId":"`+testPoolID+`","RefreshTokenValidity":"30"}`), test.physicalID)
+		switch {
+		case err != nil:
+			t.Errorf("Test number: %d failed. Error %s", i+1, err.Error())
+
+		case fmt.Sprint(kit.Calls()) != test.calls:
+			t.Errorf("Test number: %d failed. Wanted calls %s but got %v", i+1, test.calls, kit.Calls())
+
+		case result.Status != test.status:
+			t.Errorf("Test number: %d failed. Wanted status %s but got %s %+v", i+1, test.status, result.Status, result.Differences)
+		}
+	}
+}
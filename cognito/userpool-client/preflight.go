@@ -0,0 +1,213 @@
+package userpoolclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// standardScopes are the OAuth scopes that every UserPool has, the other scopes
+// belong to a resource server and are named <Identifier>/<ScopeName>.
+var standardScopes = map[string]bool{
+	"phone":                         true,
+	"email":                         true,
+	"openid":                        true,
+	"profile":                       true,
+	"aws.cognito.signin.user.admin": true,
+}
+
+// preflight takes cl and checks its OAuth settings and attributes against the UserPool and
+// its analytics role against IAM, since Cognito only returns the first problem and often
+// without saying which setting it is about. Every problem that is found is returned in the
+// same error. The checks that don't need AWS are done by validate.
+// Returns error.
+func (c *config) preflight(ctx context.Context, cl *Client) error {
+	problems := []string{}
+
+	if len(cl.ReadAttributes) > 0 || len(cl.WriteAttributes) > 0 {
+		attributes, err := c.poolAttributes(ctx, cl.UserPoolID)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, missing("ReadAttributes", "isn't an attribute of the UserPool", cl.ReadAttributes, attributes)...)
+		problems = append(problems, missing("WriteAttributes", "isn't an attribute of the UserPool", cl.WriteAttributes, attributes)...)
+	}
+
+	scopes := []string{}
+	for _, scope := range cl.AllowedOAuthScopes {
+		if !standardScopes[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) > 0 {
+		known, err := c.poolScopes(ctx, cl.UserPoolID)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, missing("AllowedOAuthScopes", "isn't a standard scope or a scope of a resource server in the UserPool", scopes, known)...)
+	}
+
+	providers := []string{}
+	for _, provider := range cl.SupportedIdentityProviders {
+		if provider != "COGNITO" {
+			providers = append(providers, provider)
+		}
+	}
+	if len(providers) > 0 {
+		known, err := c.poolProviders(ctx, cl.UserPoolID)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, missing("SupportedIdentityProviders", "isn't COGNITO or an identity provider of the UserPool", providers, known)...)
+	}
+
+	analytics, err := c.analyticsProblems(ctx, cl)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, analytics...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("The Client doesn't match UserPool %s. %s", cl.UserPoolID, strings.Join(problems, ". "))
+	}
+	return nil
+}
+
+// urlProblems checks CallbackURLs, LogoutURLs and DefaultRedirectURI against the rules of
+// Cognito. They need to be absolute without a fragment, and HTTPS unless they are for
+// localhost or use the custom scheme of an app, such as myapp://callback. Every problem
+// is an error about the property of the URL, such as CallbackURLs.0.
+// Returns []error.
+func (cl *Client) urlProblems() []error {
+	problems := []error{}
+
+	check := func(name string, path string, raw string) {
+		u, err := url.Parse(raw)
+		switch {
+		case err != nil || u.Scheme == "":
+			problems = append(problems, spec.Errorf(path, "%s %s isn't an absolute URL", name, raw))
+
+		case strings.Contains(raw, "#"):
+			problems = append(problems, spec.Errorf(path, "%s %s can't contain a fragment", name, raw))
+
+		case u.Scheme == "http" && u.Hostname() != "localhost":
+			problems = append(problems, spec.Errorf(path, "%s %s needs to use HTTPS, only localhost can use HTTP", name, raw))
+		}
+	}
+
+	for i, u := range cl.CallbackURLs {
+		check("CallbackURLs", fmt.Sprintf("CallbackURLs.%d", i), u)
+	}
+	for i, u := range cl.LogoutURLs {
+		check("LogoutURLs", fmt.Sprintf("LogoutURLs.%d", i), u)
+	}
+
+	if cl.DefaultRedirectURI != "" {
+		check("DefaultRedirectURI", "DefaultRedirectURI", cl.DefaultRedirectURI)
+
+		if !contains(cl.CallbackURLs, cl.DefaultRedirectURI) {
+			problems = append(problems, spec.Errorf("DefaultRedirectURI", "DefaultRedirectURI %s needs to be one of the CallbackURLs", cl.DefaultRedirectURI))
+		}
+	}
+	return problems
+}
+
+// missing takes name, reason, values and known and returns a problem for every value
+// that isn't known.
+// Returns []string.
+func missing(name string, reason string, values []string, known map[string]bool) []string {
+	problems := []string{}
+	for _, v := range values {
+		if !known[v] {
+			problems = append(problems, fmt.Sprintf("%s %s %s", name, v, reason))
+		}
+	}
+	return problems
+}
+
+// contains takes list and s and returns true if s is in list.
+// Returns bool.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// poolAttributes takes poolID and returns the names of the attributes of the UserPool,
+// custom attributes are named custom:<name>.
+// Returns map[string]bool and error.
+func (c *config) poolAttributes(ctx context.Context, poolID string) (map[string]bool, error) {
+	r := c.svc.DescribeUserPoolRequest(&cognitoidentityprovider.DescribeUserPoolInput{UserPoolId: &poolID})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't describe UserPool %s. Error %w", poolID, err)
+	}
+
+	attributes := map[string]bool{}
+	for _, attr := range resp.UserPool.SchemaAttributes {
+		attributes[aws.StringValue(attr.Name)] = true
+	}
+	return attributes, nil
+}
+
+// poolScopes takes poolID and returns the scopes of the resource servers of the UserPool,
+// named <Identifier>/<ScopeName>.
+// Returns map[string]bool and error.
+func (c *config) poolScopes(ctx context.Context, poolID string) (map[string]bool, error) {
+	scopes := map[string]bool{}
+	input := &cognitoidentityprovider.ListResourceServersInput{UserPoolId: &poolID, MaxResults: aws.Int64(50)}
+
+	for {
+		r := c.svc.ListResourceServersRequest(input)
+		r.SetContext(ctx)
+		resp, err := r.Send()
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't get resource servers for UserPool %s. Error %w", poolID, err)
+		}
+
+		for _, server := range resp.ResourceServers {
+			for _, scope := range server.Scopes {
+				scopes[aws.StringValue(server.Identifier)+"/"+aws.StringValue(scope.ScopeName)] = true
+			}
+		}
+
+		if resp.NextToken == nil {
+			return scopes, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// poolProviders takes poolID and returns the names of the identity providers of the UserPool.
+// Returns map[string]bool and error.
+func (c *config) poolProviders(ctx context.Context, poolID string) (map[string]bool, error) {
+	providers := map[string]bool{}
+	input := &cognitoidentityprovider.ListIdentityProvidersInput{UserPoolId: &poolID, MaxResults: aws.Int64(60)}
+
+	for {
+		r := c.svc.ListIdentityProvidersRequest(input)
+		r.SetContext(ctx)
+		resp, err := r.Send()
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't get identity providers for UserPool %s. Error %w", poolID, err)
+		}
+
+		for _, provider := range resp.Providers {
+			providers[aws.StringValue(provider.ProviderName)] = true
+		}
+
+		if resp.NextToken == nil {
+			return providers, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
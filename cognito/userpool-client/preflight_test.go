@@ -0,0 +1,211 @@
+package userpoolclient
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+// testConfig takes t and returns a config with clients that send their requests to the
+// Kit of the test, so create the Kit first.
+// Returns *config.
+func testConfig(t *testing.T) *config {
+	cfg, err := awsconfig.LoadDefault()
+	if err != nil {
+		t.Fatalf("Couldn't load AWS config. Error %s", err.Error())
+	}
+
+	clients := awsclient.FromConfig(context.Background(), cfg, ResourceType)
+	return &config{
+		svc:     clients.CognitoIdentityProvider(),
+		secrets: clients.SecretsManager(),
+		iam:     clients.IAM(),
+	}
+}
+
+// Test that the URLs are checked against the rules of Cognito, and that every problem is
+// about the property of its URL.
+func TestURLProblems(t *testing.T) {
+	tests := []struct {
+		client *Client
+		want   []string
+		paths  []string
+	}{
+		{
+			client: &Client{CallbackURLs: []string{"https://example.com/callback", "http://localhost:3000/callback", "myapp://callback"}, DefaultRedirectURI: "myapp://callback"},
+			want:   []string{},
+			paths:  []string{},
+		},
+		{
+			client: &Client{CallbackURLs: []string{"http://example.com/callback"}},
+			want:   []string{"CallbackURLs http://example.com/callback needs to use HTTPS, only localhost can use HTTP"},
+			paths:  []string{"CallbackURLs.0"},
+		},
+		{
+			client: &Client{CallbackURLs: []string{"/callback", "https://example.com/#callback"}},
+			want:   []string{"CallbackURLs /callback isn't an absolute URL", "CallbackURLs https://example.com/#callback can't contain a fragment"},
+			paths:  []string{"CallbackURLs.0", "CallbackURLs.1"},
+		},
+		{
+			client: &Client{LogoutURLs: []string{"example.com/logout", "http://127.0.0.1/logout"}},
+			want:   []string{"LogoutURLs example.com/logout isn't an absolute URL", "LogoutURLs http://127.0.0.1/logout needs to use HTTPS, only localhost can use HTTP"},
+			paths:  []string{"LogoutURLs.0", "LogoutURLs.1"},
+		},
+		{
+			client: &Client{CallbackURLs: []string{"https://example.com/callback"}, DefaultRedirectURI: "https://example.com/other"},
+			want:   []string{"DefaultRedirectURI https://example.com/other needs to be one of the CallbackURLs"},
+			paths:  []string{"DefaultRedirectURI"},
+		},
+		{
+			client: &Client{DefaultRedirectURI: "http://example.com"},
+			want:   []string{"DefaultRedirectURI http://example.com needs to use HTTPS, only localhost can use HTTP", "DefaultRedirectURI http://example.com needs to be one of the CallbackURLs"},
+			paths:  []string{"DefaultRedirectURI", "DefaultRedirectURI"},
+		},
+	}
+
+	for i, test := range tests {
+		got, paths := []string{}, []string{}
+		for _, problem := range test.client.urlProblems() {
+			got = append(got, problem.Error())
+			paths = append(paths, spec.ErrorPath(problem))
+		}
+
+		switch {
+		case !reflect.DeepEqual(got, test.want):
+			t.Errorf("Test number: %d failed. Wanted %q but got %q", i+1, test.want, got)
+
+		case !reflect.DeepEqual(paths, test.paths):
+			t.Errorf("Test number: %d failed. Wanted paths %q but got %q", i+1, test.paths, paths)
+		}
+	}
+}
+
+// Test that validate reports every invalid URL at once, about the property of the first.
+func TestValidateURLs(t *testing.T) {
+	cl := &Client{ClientName: "web", UserPoolID: testPoolID, CallbackURLs: []string{"https://example.com", "http://example.com"}, LogoutURLs: []string{"/logout"}}
+
+	err := cl.validate()
+	switch {
+	case err == nil:
+		t.Fatalf("Expected an error")
+
+	case spec.ErrorPath(err) != "CallbackURLs.1":
+		t.Errorf("Wanted error for CallbackURLs.1 but got %s", spec.ErrorPath(err))
+
+	case err.Error() != "CallbackURLs http://example.com needs to use HTTPS, only localhost can use HTTP. LogoutURLs /logout isn't an absolute URL":
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+}
+
+// Test that the scopes of every page of resource servers are returned.
+func TestPoolScopes(t *testing.T) {
+	kit := testkit.New(t)
+	kit.RespondInOrder("ListResourceServers",
+		`{"ResourceServers":[{"Identifier":"https://api.example.com","Scopes":[{"ScopeName":"read"},{"ScopeName":"write"}]}],"NextToken":"page2"}`,
+		`{"ResourceServers":[{"Identifier":"orders","Scopes":[{"ScopeName":"read"}]},{"Identifier":"empty"}]}`,
+	)
+
+	scopes, err := testConfig(t).poolScopes(context.Background(), testPoolID)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	want := map[string]bool{"https://api.example.com/read": true, "https://api.example.com/write": true, "orders/read": true}
+	if !reflect.DeepEqual(scopes, want) {
+		t.Errorf("Wanted scopes %v but got %v", want, scopes)
+	}
+
+	input := &cognitoidentityprovider.ListResourceServersInput{}
+	kit.Input("ListResourceServers", input)
+	if len(kit.Calls()) != 2 || input.NextToken == nil || *input.NextToken != "page2" {
+		t.Errorf("Expected the second page to be asked for with NextToken page2, got calls %v", kit.Calls())
+	}
+}
+
+// Test that the providers of every page are returned.
+func TestPoolProviders(t *testing.T) {
+	kit := testkit.New(t)
+	kit.RespondInOrder("ListIdentityProviders",
+		`{"Providers":[{"ProviderName":"Google","ProviderType":"Google"}],"NextToken":"page2"}`,
+		`{"Providers":[{"ProviderName":"Okta","ProviderType":"SAML"}]}`,
+	)
+
+	providers, err := testConfig(t).poolProviders(context.Background(), testPoolID)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	want := map[string]bool{"Google": true, "Okta": true}
+	if !reflect.DeepEqual(providers, want) {
+		t.Errorf("Wanted providers %v but got %v", want, providers)
+	}
+
+	input := &cognitoidentityprovider.ListIdentityProvidersInput{}
+	kit.Input("ListIdentityProviders", input)
+	if len(kit.Calls()) != 2 || input.NextToken == nil || *input.NextToken != "page2" {
+		t.Errorf("Expected the second page to be asked for with NextToken page2, got calls %v", kit.Calls())
+	}
+}
+
+// Test that every problem with the client is returned in the same error, and that
+// the UserPool is only asked about the settings that need it.
+func TestPreflight(t *testing.T) {
+	tests := []struct {
+		client   *Client
+		calls    []string
+		problems []string
+	}{
+		{
+			client: &Client{UserPoolID: testPoolID, AllowedOAuthScopes: []string{"openid", "email"}, SupportedIdentityProviders: []string{"COGNITO"}},
+			calls:  []string{},
+		},
+		{
+			client: &Client{
+				UserPoolID:                 testPoolID,
+				ReadAttributes:             []string{"email", "custom:tenant"},
+				WriteAttributes:            []string{"custom:role"},
+				AllowedOAuthScopes:         []string{"openid", "orders/read", "orders/delete"},
+				SupportedIdentityProviders: []string{"COGNITO", "Google", "Facebook"},
+			},
+			calls: []string{"DescribeUserPool", "ListResourceServers", "ListIdentityProviders"},
+			problems: []string{
+				"WriteAttributes custom:role isn't an attribute of the UserPool",
+				"AllowedOAuthScopes orders/delete isn't a standard scope",
+				"SupportedIdentityProviders Facebook isn't COGNITO or an identity provider of the UserPool",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("DescribeUserPool", `{"UserPool":{"SchemaAttributes":[{"Name":"email"},{"Name":"custom:tenant"}]}}`)
+		kit.Respond("ListResourceServers", `{"ResourceServers":[{"Identifier":"orders","Scopes":[{"ScopeName":"read"}]}]}`)
+		kit.Respond("ListIdentityProviders", `{"Providers":[{"ProviderName":"Google"}]}`)
+
+		err := testConfig(t).preflight(context.Background(), test.client)
+		switch {
+		case !reflect.DeepEqual(kit.Calls(), test.calls):
+			t.Errorf("Test number: %d failed. Wanted calls %v but got %v", i+1, test.calls, kit.Calls())
+
+		case len(test.problems) == 0 && err != nil:
+			t.Errorf("Test number: %d failed. Unexpected error %s", i+1, err.Error())
+
+		case len(test.problems) > 0 && err == nil:
+			t.Errorf("Test number: %d failed. Expected an error", i+1)
+
+		case len(test.problems) > 0:
+			for _, problem := range test.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Test number: %d failed. Expected %q in error %s", i+1, problem, err.Error())
+				}
+			}
+		}
+	}
+}
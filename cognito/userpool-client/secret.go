@@ -0,0 +1,195 @@
+package userpoolclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	l "github.com/nuttmeister/llogger"
+)
+
+// clientSecret is the content of the Secrets Manager secret that the client is stored in.
+// While the secret is rotated the old client is kept as the previous client, until
+// CloudFormation deletes it.
+type clientSecret struct {
+	UserPoolID           string `json:"UserPoolId"`
+	ClientName           string `json:"ClientName"`
+	ClientID             string `json:"ClientId"`
+	ClientSecret         string `json:"ClientSecret"`
+	PreviousClientID     string `json:"PreviousClientId,omitempty"`
+	PreviousClientSecret string `json:"PreviousClientSecret,omitempty"`
+}
+
+// storeClient takes pc and stores its ID and secret in the secret SecretName.
+// Returns string and error.
+func (c *config) storeClient(ctx context.Context, pc *cognitoidentityprovider.UserPoolClientType) (string, error) {
+	secret := &clientSecret{
+		UserPoolID:   *pc.UserPoolId,
+		ClientName:   *pc.ClientName,
+		ClientID:     *pc.ClientId,
+		ClientSecret: aws.StringValue(pc.ClientSecret),
+	}
+
+	// The old client keeps working until it's deleted, so consumers can accept both meanwhile.
+	if c.rotating {
+		old, err := c.getSecret(ctx, c.resourceProperties.SecretName)
+		if err != nil {
+			return "", err
+		}
+		if old != nil && old.ClientID != secret.ClientID {
+			secret.PreviousClientID, secret.PreviousClientSecret = old.ClientID, old.ClientSecret
+		}
+	}
+
+	return c.putSecret(ctx, secret)
+}
+
+// putSecret takes secret and stores it in the secret SecretName. The secret is
+// created if it doesn't exist, otherwise its value is replaced.
+// Returns string and error.
+func (c *config) putSecret(ctx context.Context, secret *clientSecret) (string, error) {
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't JSON Marshal the secret. Error %s", err.Error())
+	}
+
+	value := string(b)
+	description := fmt.Sprintf("Client %s of the Cognito UserPool %s, managed by %s", secret.ClientName, secret.UserPoolID, ResourceType)
+
+	var kmsKeyID *string
+	if c.resourceProperties.SecretKmsKeyID != "" {
+		kmsKeyID = &c.resourceProperties.SecretKmsKeyID
+	}
+
+	r := c.secrets.CreateSecretRequest(&secretsmanager.CreateSecretInput{
+		Name:         &c.resourceProperties.SecretName,
+		Description:  &description,
+		KmsKeyId:     kmsKeyID,
+		SecretString: &value,
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	switch {
+	case err == nil:
+		c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Created secret %s", c.resourceProperties.SecretName)})
+		return *resp.ARN, nil
+
+	case !awserrors.IsAlreadyExists(err):
+		return "", fmt.Errorf("Failed to create secret %s. Error %w", c.resourceProperties.SecretName, err)
+	}
+
+	u := c.secrets.UpdateSecretRequest(&secretsmanager.UpdateSecretInput{
+		SecretId:     &c.resourceProperties.SecretName,
+		Description:  &description,
+		KmsKeyId:     kmsKeyID,
+		SecretString: &value,
+	})
+	u.SetContext(ctx)
+	updated, err := u.Send()
+	if err != nil {
+		return "", fmt.Errorf("Failed to update secret %s. Error %w", c.resourceProperties.SecretName, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Updated secret %s", c.resourceProperties.SecretName)})
+	return *updated.ARN, nil
+}
+
+// setSecretValue takes name and secret and replaces the value of the existing secret name
+// with secret, without changing any other settings of it.
+// Returns error.
+func (c *config) setSecretValue(ctx context.Context, name string, secret *clientSecret) error {
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("Couldn't JSON Marshal the secret. Error %s", err.Error())
+	}
+
+	r := c.secrets.UpdateSecretRequest(&secretsmanager.UpdateSecretInput{
+		SecretId:     &name,
+		SecretString: aws.String(string(b)),
+	})
+	r.SetContext(ctx)
+	if _, err := r.Send(); err != nil {
+		return fmt.Errorf("Failed to update secret %s. Error %w", name, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Updated secret %s to Client %s", name, secret.ClientID)})
+	return nil
+}
+
+// getSecret takes name and returns the content of the secret name.
+// nil is returned if the secret doesn't exist or doesn't contain a client.
+// Returns *clientSecret and error.
+func (c *config) getSecret(ctx context.Context, name string) (*clientSecret, error) {
+	r := c.secrets.GetSecretValueRequest(&secretsmanager.GetSecretValueInput{SecretId: &name})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	switch {
+	case awserrors.IsNotFound(err):
+		return nil, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("Failed to get secret %s. Error %w", name, err)
+	}
+
+	secret := &clientSecret{}
+	if err := json.Unmarshal([]byte(aws.StringValue(resp.SecretString)), secret); err != nil || secret.ClientID == "" {
+		return nil, nil
+	}
+	return secret, nil
+}
+
+// deleteSecret takes name and id and removes the client with id from the secret name.
+//
+//   - If the client is the previous client of a rotation, only the previous client is removed.
+//   - If the client was rotated in but is deleted, as on rollback, the previous client is put back.
+//   - If the secret only contains the client, the secret is deleted.
+//
+// The secret is kept as is if it contains another client, which is the case when the
+// client was replaced and the new client already took over the secret.
+// Returns error.
+func (c *config) deleteSecret(ctx context.Context, name string, id string) error {
+	if name == "" || id == "" {
+		return nil
+	}
+
+	secret, err := c.getSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case secret == nil:
+		return nil
+
+	case secret.PreviousClientID == id:
+		secret.PreviousClientID, secret.PreviousClientSecret = "", ""
+		return c.setSecretValue(ctx, name, secret)
+
+	case secret.ClientID == id && secret.PreviousClientID != "":
+		secret.ClientID, secret.ClientSecret = secret.PreviousClientID, secret.PreviousClientSecret
+		secret.PreviousClientID, secret.PreviousClientSecret = "", ""
+		return c.setSecretValue(ctx, name, secret)
+
+	case secret.ClientID != id:
+		c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Keeping secret %s since it doesn't contain Client %s", name, id)})
+		return nil
+	}
+
+	// The client is deleted or moved to another secret, so there is nothing to recover.
+	// Deleting without recovery also lets a new secret with the same name be created at once.
+	r := c.secrets.DeleteSecretRequest(&secretsmanager.DeleteSecretInput{
+		SecretId:                   &name,
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	r.SetContext(ctx)
+	if _, err := r.Send(); err != nil && !awserrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete secret %s. Error %w", name, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Deleted secret %s", name)})
+	return nil
+}
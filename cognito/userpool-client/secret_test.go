@@ -0,0 +1,171 @@
+package userpoolclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+const (
+	testPoolID     = "eu-west-1_abc123"
+	testSecretName = "web-client"
+)
+
+// testProperties returns the properties of a client that is stored in a secret and
+// rotated with rotationID.
+// Returns map[string]string.
+func testProperties(rotationID string) map[string]string {
+	return map[string]string{
+		"ClientName":       "web",
+		"UserPoolId":       testPoolID,
+		"GenerateSecret":   "true",
+		"SecretName":       testSecretName,
+		"SecretRotationId": rotationID,
+	}
+}
+
+// respondSecret takes t, kit and secret and mocks GetSecretValue to return secret.
+func respondSecret(t *testing.T, kit *testkit.Kit, secret *clientSecret) {
+	value, err := json.Marshal(secret)
+	if err != nil {
+		t.Fatalf("Couldn't marshal secret. Error %s", err.Error())
+	}
+
+	b, err := json.Marshal(map[string]string{"ARN": "arn:aws:secretsmanager:eu-west-1:123456789012:secret:web-client-AbCdEf", "SecretString": string(value)})
+	if err != nil {
+		t.Fatalf("Couldn't marshal secret. Error %s", err.Error())
+	}
+	kit.Respond("GetSecretValue", string(b))
+}
+
+// updatedSecret takes t and kit and returns the secret of the last call to UpdateSecret.
+// Returns *clientSecret.
+func updatedSecret(t *testing.T, kit *testkit.Kit) *clientSecret {
+	input := &secretsmanager.UpdateSecretInput{}
+	kit.Input("UpdateSecret", input)
+
+	secret := &clientSecret{}
+	if err := json.Unmarshal([]byte(*input.SecretString), secret); err != nil {
+		t.Fatalf("Couldn't unmarshal SecretString. Error %s", err.Error())
+	}
+	return secret
+}
+
+// Test that rotating the secret keeps the old client as the previous client, and that
+// the Delete of the old client that CloudFormation sends after the update removes it again.
+func TestRotateSecret(t *testing.T) {
+	kit := testkit.New(t)
+	kit.Respond("CreateUserPoolClient", `{"UserPoolClient":{"ClientId":"new","ClientName":"web","UserPoolId":"`+testPoolID+`"}}`)
+	kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientId":"new","ClientName":"web","UserPoolId":"`+testPoolID+`","ClientSecret":"new-secret"}}`)
+	kit.Fail("CreateSecret", "ResourceExistsException", "The secret already exists")
+	kit.Respond("UpdateSecret", `{"ARN":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:web-client-AbCdEf"}`)
+	respondSecret(t, kit, &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "old", ClientSecret: "old-secret"})
+
+	req := kit.Request(events.RequestUpdate, ResourceType, testProperties("2"), testProperties("1"))
+	req.PhysicalResourceID = testPoolID + "/old"
+
+	resp := kit.Run(Handler, req)
+	switch {
+	case resp.Status != "SUCCESS":
+		t.Fatalf("Expected SUCCESS but got %s. Reason %s", resp.Status, resp.Reason)
+
+	case resp.PhysicalResourceID != testPoolID+"/new":
+		t.Errorf("Expected physical ID %s/new but got %s", testPoolID, resp.PhysicalResourceID)
+
+	case resp.Data["ClientSecret"] != "":
+		t.Errorf("Expected no ClientSecret in the data when SecretName is set")
+	}
+
+	want := &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "new", ClientSecret: "new-secret", PreviousClientID: "old", PreviousClientSecret: "old-secret"}
+	if got := updatedSecret(t, kit); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected rotated secret %+v but got %+v", want, got)
+	}
+
+	// CloudFormation deletes the old client with the old properties once the update is done.
+	respondSecret(t, kit, want)
+	kit.Respond("DeleteUserPoolClient", "")
+
+	req = kit.Request(events.RequestDelete, ResourceType, testProperties("1"), nil)
+	req.PhysicalResourceID = testPoolID + "/old"
+
+	if resp := kit.Run(Handler, req); resp.Status != "SUCCESS" {
+		t.Fatalf("Expected SUCCESS but got %s. Reason %s", resp.Status, resp.Reason)
+	}
+
+	want = &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "new", ClientSecret: "new-secret"}
+	if got := updatedSecret(t, kit); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected secret %+v after cleanup but got %+v", want, got)
+	}
+}
+
+// Test what a Delete does to the secret depending on which clients it contains.
+func TestDeleteSecret(t *testing.T) {
+	tests := []struct {
+		secret *clientSecret
+		calls  string
+		want   *clientSecret
+	}{
+		// Rollback of a rotation, the previous client is put back.
+		{
+			secret: &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "new", ClientSecret: "new-secret", PreviousClientID: "old", PreviousClientSecret: "old-secret"},
+			calls:  "[GetSecretValue UpdateSecret DeleteUserPoolClient]",
+			want:   &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "old", ClientSecret: "old-secret"},
+		},
+		// The secret only contains the client, so it's deleted.
+		{
+			secret: &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "new", ClientSecret: "new-secret"},
+			calls:  "[GetSecretValue DeleteSecret DeleteUserPoolClient]",
+		},
+		// The secret belongs to another client, which took it over after a replacement.
+		{
+			secret: &clientSecret{UserPoolID: testPoolID, ClientName: "web", ClientID: "other", ClientSecret: "other-secret"},
+			calls:  "[GetSecretValue DeleteUserPoolClient]",
+		},
+		// The secret doesn't contain a client at all.
+		{
+			secret: &clientSecret{},
+			calls:  "[GetSecretValue DeleteUserPoolClient]",
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		respondSecret(t, kit, test.secret)
+		kit.Respond("UpdateSecret", `{"ARN":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:web-client-AbCdEf"}`)
+		kit.Respond("DeleteSecret", "")
+		kit.Respond("DeleteUserPoolClient", "")
+
+		req := kit.Request(events.RequestDelete, ResourceType, testProperties("2"), nil)
+		req.PhysicalResourceID = testPoolID + "/new"
+
+		resp := kit.Run(Handler, req)
+		switch {
+		case resp.Status != "SUCCESS":
+			t.Errorf("Test number: %d failed. Expected SUCCESS but got %s. Reason %s", i+1, resp.Status, resp.Reason)
+			continue
+
+		case fmt.Sprint(kit.Calls()) != test.calls:
+			t.Errorf("Test number: %d failed. Wanted calls %s but got %v", i+1, test.calls, kit.Calls())
+			continue
+		}
+
+		if test.want != nil {
+			if got := updatedSecret(t, kit); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Test number: %d failed. Wanted secret %+v but got %+v", i+1, test.want, got)
+			}
+		}
+
+		if test.calls == "[GetSecretValue DeleteSecret DeleteUserPoolClient]" {
+			input := &secretsmanager.DeleteSecretInput{}
+			kit.Input("DeleteSecret", input)
+			if input.ForceDeleteWithoutRecovery == nil || !*input.ForceDeleteWithoutRecovery {
+				t.Errorf("Test number: %d failed. Expected the secret to be deleted without recovery", i+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package userpoolclient
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// TokenValidityUnitsType contains the units of the token validities.
+type TokenValidityUnitsType struct {
+	AccessToken  string `json:"AccessToken,omitempty" cfn:"enum=seconds|minutes|hours|days" doc:"The unit of AccessTokenValidity. Defaults to hours"`
+	IDToken      string `json:"IdToken,omitempty" cfn:"enum=seconds|minutes|hours|days" doc:"The unit of IdTokenValidity. Defaults to hours"`
+	RefreshToken string `json:"RefreshToken,omitempty" cfn:"enum=seconds|minutes|hours|days" doc:"The unit of RefreshTokenValidity. Defaults to days"`
+}
+
+// clientSettings are the settings of the client that the AWS SDK in use doesn't know yet.
+// They're sent with awsclient.WithParams and read back with awsclient.WithResult.
+type clientSettings struct {
+	AccessTokenValidity        *int64                  `json:"AccessTokenValidity,omitempty"`
+	IDTokenValidity            *int64                  `json:"IdTokenValidity,omitempty"`
+	TokenValidityUnits         *TokenValidityUnitsType `json:"TokenValidityUnits,omitempty"`
+	PreventUserExistenceErrors string                  `json:"PreventUserExistenceErrors,omitempty"`
+	EnableTokenRevocation      *bool                   `json:"EnableTokenRevocation,omitempty"`
+	AuthSessionValidity        *int64                  `json:"AuthSessionValidity,omitempty"`
+	AnalyticsConfiguration     *analyticsSettings      `json:"AnalyticsConfiguration,omitempty"`
+}
+
+// units contains the valid units of the token validities.
+var units = map[string]time.Duration{
+	"seconds": time.Second,
+	"minutes": time.Minute,
+	"hours":   time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// validity contains a token validity with the range that Cognito allows for it.
+type validity struct {
+	name     string
+	value    string
+	unit     string
+	unitName string
+	min, max time.Duration
+}
+
+// validities returns the token validities of the client with their units and allowed ranges.
+// Returns []validity.
+func (cl *Client) validities() []validity {
+	u := TokenValidityUnitsType{AccessToken: "hours", IDToken: "hours", RefreshToken: "days"}
+	if cl.TokenValidityUnits != nil {
+		if cl.TokenValidityUnits.AccessToken != "" {
+			u.AccessToken = cl.TokenValidityUnits.AccessToken
+		}
+		if cl.TokenValidityUnits.IDToken != "" {
+			u.IDToken = cl.TokenValidityUnits.IDToken
+		}
+		if cl.TokenValidityUnits.RefreshToken != "" {
+			u.RefreshToken = cl.TokenValidityUnits.RefreshToken
+		}
+	}
+
+	return []validity{
+		{name: "AccessTokenValidity", value: cl.AccessTokenValidity, unit: u.AccessToken, unitName: "TokenValidityUnits.AccessToken", min: 5 * time.Minute, max: 24 * time.Hour},
+		{name: "IdTokenValidity", value: cl.IDTokenValidity, unit: u.IDToken, unitName: "TokenValidityUnits.IdToken", min: 5 * time.Minute, max: 24 * time.Hour},
+		{name: "RefreshTokenValidity", value: cl.RefreshTokenValidity, unit: u.RefreshToken, unitName: "TokenValidityUnits.RefreshToken", min: time.Hour, max: 3650 * 24 * time.Hour},
+		{name: "AuthSessionValidity", value: cl.AuthSessionValidity, unit: "minutes", min: 3 * time.Minute, max: 15 * time.Minute},
+	}
+}
+
+// validateSettings validates the token validities and the other settings that Cognito
+// only accepts certain values for.
+// Returns error.
+func (cl *Client) validateSettings() error {
+	for _, v := range cl.validities() {
+		if _, ok := units[v.unit]; !ok {
+			return spec.Errorf(v.unitName, "%s needs to be seconds, minutes, hours or days", v.unitName)
+		}
+
+		if v.value == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(v.value, 10, 64)
+		if err != nil {
+			return spec.Errorf(v.name, "%s invalid", v.name)
+		}
+
+		if d := time.Duration(n) * units[v.unit]; n < 0 || d < v.min || d > v.max {
+			return spec.Errorf(v.name, "%s needs to be between %s and %s, got %d %s", v.name, describe(v.min), describe(v.max), n, v.unit)
+		}
+	}
+
+	switch cl.PreventUserExistenceErrors {
+	case "", "ENABLED", "LEGACY":
+	default:
+		return spec.Errorf("PreventUserExistenceErrors", "PreventUserExistenceErrors needs to be ENABLED or LEGACY")
+	}
+
+	switch cl.EnableTokenRevocation {
+	case "", "true", "false":
+	default:
+		return spec.Errorf("EnableTokenRevocation", "EnableTokenRevocation needs to be true or false")
+	}
+	return nil
+}
+
+// settings returns the settings of the client that are sent with awsclient.WithParams.
+// The client needs to be validated first.
+// Returns *clientSettings.
+func (cl *Client) settings() *clientSettings {
+	s := &clientSettings{
+		AccessTokenValidity:        parseInt(cl.AccessTokenValidity),
+		IDTokenValidity:            parseInt(cl.IDTokenValidity),
+		TokenValidityUnits:         cl.TokenValidityUnits,
+		PreventUserExistenceErrors: cl.PreventUserExistenceErrors,
+		AuthSessionValidity:        parseInt(cl.AuthSessionValidity),
+	}
+
+	if cl.EnableTokenRevocation != "" {
+		enabled := cl.EnableTokenRevocation == "true"
+		s.EnableTokenRevocation = &enabled
+	}
+	if cl.AnalyticsConfiguration != nil {
+		s.AnalyticsConfiguration = cl.AnalyticsConfiguration.params()
+	}
+	return s
+}
+
+// parseInt takes s and returns it as a number, nil is returned if s isn't a number.
+// Returns *int64.
+func parseInt(s string) *int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// formatInt takes n and returns it as a string, empty string is returned if n is nil.
+// Returns string.
+func formatInt(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+// describe returns d in the largest unit that it's a whole number of, such as "1 day".
+// Returns string.
+func describe(d time.Duration) string {
+	unit := "seconds"
+	for _, u := range []string{"days", "hours", "minutes"} {
+		if d%units[u] == 0 {
+			unit = u
+			break
+		}
+	}
+
+	n := int64(d / units[unit])
+	if n == 1 {
+		return "1 " + strings.TrimSuffix(unit, "s")
+	}
+	return strconv.FormatInt(n, 10) + " " + unit
+}
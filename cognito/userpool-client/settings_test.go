@@ -0,0 +1,94 @@
+package userpoolclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Test that the token validities are checked against the ranges of Cognito in their units.
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		client *Client
+		path   string // The property that the error is about, empty if there is no error.
+	}{
+		{client: &Client{}},
+		{client: &Client{AccessTokenValidity: "1", IDTokenValidity: "24", RefreshTokenValidity: "30", AuthSessionValidity: "3"}},
+		{client: &Client{AccessTokenValidity: "5", TokenValidityUnits: &TokenValidityUnitsType{AccessToken: "minutes"}}},
+		{client: &Client{AccessTokenValidity: "4", TokenValidityUnits: &TokenValidityUnitsType{AccessToken: "minutes"}}, path: "AccessTokenValidity"},
+		{client: &Client{AccessTokenValidity: "25"}, path: "AccessTokenValidity"},
+		{client: &Client{AccessTokenValidity: "86400", TokenValidityUnits: &TokenValidityUnitsType{AccessToken: "seconds"}}},
+		{client: &Client{AccessTokenValidity: "-1"}, path: "AccessTokenValidity"},
+		{client: &Client{IDTokenValidity: "one"}, path: "IdTokenValidity"},
+		{client: &Client{IDTokenValidity: "2", TokenValidityUnits: &TokenValidityUnitsType{IDToken: "days"}}, path: "IdTokenValidity"},
+		{client: &Client{RefreshTokenValidity: "3650"}},
+		{client: &Client{RefreshTokenValidity: "3651"}, path: "RefreshTokenValidity"},
+		{client: &Client{RefreshTokenValidity: "60", TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "minutes"}}},
+		{client: &Client{RefreshTokenValidity: "59", TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "minutes"}}, path: "RefreshTokenValidity"},
+		{client: &Client{TokenValidityUnits: &TokenValidityUnitsType{RefreshToken: "weeks"}}, path: "TokenValidityUnits.RefreshToken"},
+		{client: &Client{AuthSessionValidity: "2"}, path: "AuthSessionValidity"},
+		{client: &Client{AuthSessionValidity: "16"}, path: "AuthSessionValidity"},
+		{client: &Client{PreventUserExistenceErrors: "LEGACY", EnableTokenRevocation: "false"}},
+		{client: &Client{PreventUserExistenceErrors: "enabled"}, path: "PreventUserExistenceErrors"},
+		{client: &Client{EnableTokenRevocation: "yes"}, path: "EnableTokenRevocation"},
+	}
+
+	for i, test := range tests {
+		err := test.client.validateSettings()
+		switch {
+		case test.path == "" && err != nil:
+			t.Errorf("Test number: %d failed. Unexpected error %s", i+1, err.Error())
+
+		case test.path != "" && err == nil:
+			t.Errorf("Test number: %d failed. Expected an error for %s", i+1, test.path)
+
+		case test.path != "" && spec.ErrorPath(err) != test.path:
+			t.Errorf("Test number: %d failed. Wanted error for %s but got %s", i+1, test.path, err.Error())
+		}
+	}
+}
+
+// Test that the validities get the default units unless TokenValidityUnits sets them.
+func TestValidities(t *testing.T) {
+	tests := []struct {
+		units *TokenValidityUnitsType
+		want  []string
+	}{
+		{units: nil, want: []string{"hours", "hours", "days", "minutes"}},
+		{units: &TokenValidityUnitsType{}, want: []string{"hours", "hours", "days", "minutes"}},
+		{units: &TokenValidityUnitsType{AccessToken: "minutes", RefreshToken: "hours"}, want: []string{"minutes", "hours", "hours", "minutes"}},
+	}
+
+	for i, test := range tests {
+		got := []string{}
+		for _, v := range (&Client{TokenValidityUnits: test.units}).validities() {
+			got = append(got, v.unit)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Test number: %d failed. Wanted %v but got %v", i+1, test.want, got)
+		}
+	}
+}
+
+// Test that durations are described in the largest whole unit.
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 24 * time.Hour, want: "1 day"},
+		{d: 3650 * 24 * time.Hour, want: "3650 days"},
+		{d: 90 * time.Minute, want: "90 minutes"},
+		{d: 5 * time.Minute, want: "5 minutes"},
+		{d: 30 * time.Second, want: "30 seconds"},
+	}
+
+	for i, test := range tests {
+		if got := describe(test.d); got != test.want {
+			t.Errorf("Test number: %d failed. Wanted %s but got %s", i+1, test.want, got)
+		}
+	}
+}
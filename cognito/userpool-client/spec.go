@@ -0,0 +1,89 @@
+package userpoolclient
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito UserPool Client CloudFormation Support",
+	Properties:  Client{},
+	Attributes: []spec.Attribute{
+		{Name: "ClientName", Description: "The name of the Client"},
+		{Name: "ClientId", Description: "The ID of the Client"},
+		{Name: "UserPoolId", Description: "The ID of the UserPool"},
+		{Name: "ClientSecret", Description: "The secret of the Client, only set if GenerateSecret is true and SecretName isn't set"},
+		{Name: "SecretArn", Description: "The ARN of the secret that contains the ClientId and ClientSecret, only set if SecretName is set"},
+		{Name: "GenerateSecret", Description: "true if the Client has a secret, otherwise false"},
+		{Name: "RefreshTokenValidity", Description: "Refresh token validity in TokenValidityUnits.RefreshToken"},
+		{Name: "ReadAttributes", Description: "Comma separated Read Attributes"},
+		{Name: "WriteAttributes", Description: "Comma separated Write Attributes"},
+		{Name: "ExplicitAuthFlows", Description: "Comma separated Explicit Auth Flows"},
+		{Name: "AllowedOAuthFlows", Description: "Comma separated Allowed OAuth Flows"},
+		{Name: "AllowedOAuthFlowsUserPoolClient", Description: "true if the Client is allowed to use OAuth, otherwise false"},
+		{Name: "AllowedOAuthScopes", Description: "Comma separated Allowed OAuth Scopes"},
+		{Name: "CallbackURLs", Description: "Comma separated Callback URLs"},
+		{Name: "LogoutURLs", Description: "Comma separated Logout URLs"},
+		{Name: "DefaultRedirectURI", Description: "Default Redirect URI"},
+		{Name: "SupportedIdentityProviders", Description: "Comma separated names of the supported providers"},
+		{Name: "AccessTokenValidity", Description: "Access token validity in TokenValidityUnits.AccessToken"},
+		{Name: "IdTokenValidity", Description: "ID token validity in TokenValidityUnits.IdToken"},
+		{Name: "TokenValidityUnits.AccessToken", Description: "The unit of AccessTokenValidity"},
+		{Name: "TokenValidityUnits.IdToken", Description: "The unit of IdTokenValidity"},
+		{Name: "TokenValidityUnits.RefreshToken", Description: "The unit of RefreshTokenValidity"},
+		{Name: "AuthSessionValidity", Description: "Validity of the session token of an authentication flow in minutes"},
+		{Name: "PreventUserExistenceErrors", Description: "ENABLED or LEGACY"},
+		{Name: "EnableTokenRevocation", Description: "true if refresh tokens can be revoked, otherwise false"},
+		{Name: "AnalyticsConfiguration.ApplicationArn", Description: "ARN of the Pinpoint app that analytics are sent to"},
+		{Name: "AnalyticsConfiguration.ApplicationId", Description: "ID of the Pinpoint app that analytics are sent to"},
+		{Name: "AnalyticsConfiguration.ExternalId", Description: "External ID that Cognito uses when it assumes the analytics role"},
+		{Name: "AnalyticsConfiguration.RoleArn", Description: "ARN of the analytics role"},
+		{Name: "AnalyticsConfiguration.UserDataShared", Description: "true if user data is shared with Pinpoint, otherwise false"},
+		{Name: "CreationDate", Description: "When the Client was created, in RFC 3339 format"},
+		{Name: "LastModifiedDate", Description: "When the Client was last modified, in RFC 3339 format"},
+		{Name: "PreservedProperties", Description: "Comma separated properties that MergeMode kept from the live Client in the last Update"},
+	},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-idp:CreateUserPoolClient",
+				"cognito-idp:DeleteUserPoolClient",
+				"cognito-idp:DescribeUserPool",
+				"cognito-idp:DescribeUserPoolClient",
+				"cognito-idp:ListIdentityProviders",
+				"cognito-idp:ListResourceServers",
+				"cognito-idp:ListUserPoolClients",
+				"cognito-idp:UpdateUserPoolClient",
+			},
+			Resources: []string{"arn:aws:cognito-idp:*:${AWS::AccountId}:userpool/*"},
+		},
+		{
+			Comment:   "Only used to check the role in AnalyticsConfiguration.RoleArn before it's given to Cognito.",
+			Actions:   []string{"iam:GetRole", "iam:SimulatePrincipalPolicy"},
+			Resources: []string{"arn:aws:iam::${AWS::AccountId}:role/*"},
+		},
+		{
+			Comment:   "Only used to delete clients that were created before they were tracked by their ID.",
+			Actions:   []string{"cloudformation:DescribeStackResource"},
+			Resources: []string{"arn:aws:cloudformation:${AWS::Region}:${AWS::AccountId}:stack/*"},
+		},
+		{
+			Comment: "Only used when the SecretName property is set on the custom resource.",
+			Actions: []string{
+				"secretsmanager:CreateSecret",
+				"secretsmanager:DeleteSecret",
+				"secretsmanager:GetSecretValue",
+				"secretsmanager:UpdateSecret",
+			},
+			Resources: []string{"arn:aws:secretsmanager:*:${AWS::AccountId}:secret:*"},
+		},
+		{
+			Comment: "Only used when the SecretKmsKeyId property is set, Secrets Manager encrypts the secret with the key on behalf of the function.",
+			Actions: []string{
+				"kms:Decrypt",
+				"kms:GenerateDataKey",
+			},
+			Resources: []string{"arn:aws:kms:*:${AWS::AccountId}:key/*"},
+			Implicit:  true,
+		},
+	},
+}
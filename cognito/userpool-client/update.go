@@ -1,109 +1,110 @@
-package main
+package userpoolclient
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 )
 
 // updateClient will update the client on the user pool with settings specified by req.
 // Returns map of string that is data that Fn::GetAtt can use.
 // Returns map[string]string error.
-func (c *config) updateClient(req *events.Request, id string) (map[string]string, error) {
+func (c *config) updateClient(ctx context.Context, req *events.Request, id string) (map[string]string, error) {
 
 	// Simple validation that will result in error.
-	switch {
-	case id == "":
+	if id == "" {
 		return nil, fmt.Errorf("ClientId can't be empty")
+	}
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
 
-	case c.resourceProperties.ClientName == "":
-		return nil, fmt.Errorf("ClientName can't be empty")
-
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("UserPoolId can't be empty")
+	// Keep the settings that aren't declared if MergeMode is set.
+	cl := c.resourceProperties
+	if cl.MergeMode == "true" {
+		merged, err := c.mergeClient(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		cl = merged
+	}
+	if err := c.preflight(ctx, cl); err != nil {
+		return nil, err
 	}
 
 	// Set oauthFlows
 	oauthFlows := false
-	if c.resourceProperties.AllowedOAuthFlowsUserPoolClient == "true" {
+	if cl.AllowedOAuthFlowsUserPoolClient == "true" {
 		oauthFlows = true
 	}
 
 	input := &cognitoidentityprovider.UpdateUserPoolClientInput{
 		ClientId:                        &id,
-		ClientName:                      &c.resourceProperties.ClientName,
-		UserPoolId:                      &c.resourceProperties.UserPoolID,
+		ClientName:                      &cl.ClientName,
+		UserPoolId:                      &cl.UserPoolID,
 		AllowedOAuthFlowsUserPoolClient: &oauthFlows,
 	}
 
 	// Set optional settings.
-	if c.resourceProperties.RefreshTokenValidity != "" {
-		n, err := strconv.ParseInt(c.resourceProperties.RefreshTokenValidity, 10, 64)
+	if cl.RefreshTokenValidity != "" {
+		n, err := strconv.ParseInt(cl.RefreshTokenValidity, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("RefreshTokenValidity invalid")
 		}
 		input.RefreshTokenValidity = &n
 	}
-	if c.resourceProperties.ReadAttributes != nil {
-		input.ReadAttributes = c.resourceProperties.ReadAttributes
-	}
-	if c.resourceProperties.WriteAttributes != nil {
-		input.WriteAttributes = c.resourceProperties.WriteAttributes
+	if cl.ReadAttributes != nil {
+		input.ReadAttributes = cl.ReadAttributes
 	}
-	if c.resourceProperties.ExplicitAuthFlows != nil {
-		input.ExplicitAuthFlows = c.resourceProperties.ExplicitAuthFlows
+	if cl.WriteAttributes != nil {
+		input.WriteAttributes = cl.WriteAttributes
 	}
-	if c.resourceProperties.AllowedOAuthFlows != nil {
-		input.AllowedOAuthFlows = c.resourceProperties.AllowedOAuthFlows
+	if cl.ExplicitAuthFlows != nil {
+		input.ExplicitAuthFlows = cl.ExplicitAuthFlows
 	}
-	if c.resourceProperties.AllowedOAuthScopes != nil {
-		input.AllowedOAuthScopes = c.resourceProperties.AllowedOAuthScopes
+	if cl.AllowedOAuthFlows != nil {
+		input.AllowedOAuthFlows = cl.AllowedOAuthFlows
 	}
-	if c.resourceProperties.CallbackURLs != nil {
-		input.CallbackURLs = c.resourceProperties.CallbackURLs
+	if cl.AllowedOAuthScopes != nil {
+		input.AllowedOAuthScopes = cl.AllowedOAuthScopes
 	}
-	if c.resourceProperties.LogoutURLs != nil {
-		input.LogoutURLs = c.resourceProperties.LogoutURLs
+	if cl.CallbackURLs != nil {
+		input.CallbackURLs = cl.CallbackURLs
 	}
-	if c.resourceProperties.DefaultRedirectURI != "" {
-		input.DefaultRedirectURI = &c.resourceProperties.DefaultRedirectURI
+	if cl.LogoutURLs != nil {
+		input.LogoutURLs = cl.LogoutURLs
 	}
-	if c.resourceProperties.SupportedIdentityProviders != nil {
-		input.SupportedIdentityProviders = c.resourceProperties.SupportedIdentityProviders
+	if cl.DefaultRedirectURI != "" {
+		input.DefaultRedirectURI = &cl.DefaultRedirectURI
 	}
-
-	// Set Analytics
-	if c.resourceProperties.AnalyticsConfiguration != nil {
-		input.AnalyticsConfiguration = &cognitoidentityprovider.AnalyticsConfigurationType{UserDataShared: &c.resourceProperties.AnalyticsConfiguration.UserDataShared}
-
-		if c.resourceProperties.AnalyticsConfiguration.ApplicationID != "" {
-			input.AnalyticsConfiguration.ApplicationId = &c.resourceProperties.AnalyticsConfiguration.ApplicationID
-		}
-		if c.resourceProperties.AnalyticsConfiguration.ExternalID != "" {
-			input.AnalyticsConfiguration.ExternalId = &c.resourceProperties.AnalyticsConfiguration.ExternalID
-		}
-		if c.resourceProperties.AnalyticsConfiguration.RoleArn != "" {
-			input.AnalyticsConfiguration.RoleArn = &c.resourceProperties.AnalyticsConfiguration.RoleArn
-		}
+	if cl.SupportedIdentityProviders != nil {
+		input.SupportedIdentityProviders = cl.SupportedIdentityProviders
 	}
 
-	resp, err := c.svc.UpdateUserPoolClientRequest(input).Send()
+	r := c.svc.UpdateUserPoolClientRequest(input)
+	r.SetContext(ctx)
+	awsclient.WithParams(r.Request, cl.settings())
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to update Client. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to update Client. Error %w", err)
 	}
+	c.trackClient(req, *resp.UserPoolClient.ClientId)
 
-	attr := map[string]string{
-		"ClientName": *resp.UserPoolClient.ClientName,
-		"ClientId":   *resp.UserPoolClient.ClientId,
-		"UserPoolId": *resp.UserPoolClient.UserPoolId,
+	data, err := c.clientData(ctx, *resp.UserPoolClient.ClientId)
+	if err != nil {
+		return nil, err
 	}
 
-	// Only set ClientSecret if it's set.
-	if resp.UserPoolClient.ClientSecret != nil {
-		attr["ClientSecret"] = *resp.UserPoolClient.ClientSecret
+	// The old secret is no longer managed if SecretName was changed or removed.
+	if old := c.oldResourceProperties.SecretName; old != c.resourceProperties.SecretName {
+		if err := c.deleteSecret(ctx, old, id); err != nil {
+			return nil, err
+		}
 	}
 
-	return attr, nil
+	return data, nil
 }
@@ -0,0 +1,77 @@
+package userpoolclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// secretNameRegexp matches the names that Secrets Manager accepts.
+var secretNameRegexp = regexp.MustCompile(`^[A-Za-z0-9/_+=.@-]{1,512}$`)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &Client{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the settings of the client.
+// Returns error.
+func (cl *Client) validate() error {
+	switch {
+	case cl.ClientName == "":
+		return spec.Errorf("ClientName", "ClientName can't be empty")
+
+	case cl.UserPoolID == "":
+		return spec.Errorf("UserPoolId", "UserPoolId can't be empty")
+	}
+
+	if err := cl.validateSettings(); err != nil {
+		return err
+	}
+
+	// Cognito only reports the first invalid URL, so every problem is reported at once
+	// and the error is about the property of the first.
+	if problems := cl.urlProblems(); len(problems) > 0 {
+		messages := []string{}
+		for _, problem := range problems {
+			messages = append(messages, problem.Error())
+		}
+		return spec.Errorf(spec.ErrorPath(problems[0]), "%s", strings.Join(messages, ". "))
+	}
+
+	if cl.AnalyticsConfiguration != nil {
+		if err := cl.AnalyticsConfiguration.validate(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case cl.SecretName != "" && !secretNameRegexp.MatchString(cl.SecretName):
+		return spec.Errorf("SecretName", "SecretName can only contain letters, numbers and /_+=.@- and be at most 512 characters")
+
+	case cl.SecretName != "" && cl.GenerateSecret != "true":
+		return spec.Errorf("SecretName", "SecretName requires GenerateSecret to be true")
+
+	case cl.SecretRotationID != "" && cl.GenerateSecret != "true":
+		return spec.Errorf("SecretRotationId", "SecretRotationId requires GenerateSecret to be true")
+
+	case cl.SecretKmsKeyID != "" && cl.SecretName == "":
+		return spec.Errorf("SecretKmsKeyId", "SecretKmsKeyId requires SecretName to be set")
+	}
+
+	switch cl.MergeMode {
+	case "", "true", "false":
+	default:
+		return spec.Errorf("MergeMode", "MergeMode needs to be true or false")
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
-package main
+package userpooldomain
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 )
@@ -10,13 +12,9 @@ import (
 // createDomain will create a new domain on the user pool with
 // settings specified by req.
 // Returns ap[string]string and error.
-func (c *config) createDomain(req *events.Request) (map[string]string, error) {
-	switch {
-	case c.resourceProperties.Domain == "":
-		return nil, fmt.Errorf("No Domain specified")
-
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("No UserPoolId specified")
+func (c *config) createDomain(ctx context.Context, req *events.Request) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
 	}
 
 	input := &cognitoidentityprovider.CreateUserPoolDomainInput{
@@ -31,15 +29,26 @@ func (c *config) createDomain(req *events.Request) (map[string]string, error) {
 		}
 	}
 
-	resp, err := c.svc.CreateUserPoolDomainRequest(input).Send()
+	r := c.svc.CreateUserPoolDomainRequest(input)
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create Domain. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to create Domain. Error %w", err)
 	}
 
-	data := map[string]string{}
-	if resp.CloudFrontDomain != nil {
-		data["Domain"] = *resp.CloudFrontDomain
+	data := domainData(aws.StringValue(resp.CloudFrontDomain))
+	if err := c.upsertRecords(ctx, data["CloudFrontDistribution"]); err != nil {
+		return nil, err
 	}
-
 	return data, nil
 }
+
+// domainData takes cloudFrontDomain and returns the attributes of the domain. Domain is
+// kept for templates that used it before CloudFrontDistribution was added.
+// Returns map[string]string.
+func domainData(cloudFrontDomain string) map[string]string {
+	return map[string]string{
+		"Domain":                 cloudFrontDomain,
+		"CloudFrontDistribution": cloudFrontDomain,
+	}
+}
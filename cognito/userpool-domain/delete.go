@@ -1,6 +1,7 @@
-package main
+package userpooldomain
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -10,7 +11,7 @@ import (
 // deleteDomain will delete the domain specified in req.
 // If old is true we delete from oldResourceProperties.
 // Returns error.
-func (c *config) deleteDomain(req *events.Request, old bool) error {
+func (c *config) deleteDomain(ctx context.Context, req *events.Request, old bool) error {
 	props := c.resourceProperties
 	if old {
 		props = c.oldResourceProperties
@@ -26,13 +27,15 @@ func (c *config) deleteDomain(req *events.Request, old bool) error {
 		return nil
 	}
 
-	_, err := c.svc.DeleteUserPoolDomainRequest(
+	r := c.svc.DeleteUserPoolDomainRequest(
 		&cognitoidentityprovider.DeleteUserPoolDomainInput{
 			Domain:     &props.Domain,
 			UserPoolId: &props.UserPoolID,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to delete Domain. Error %s", err.Error())
+		return fmt.Errorf("Failed to delete Domain. Error %w", err)
 	}
 
 	return nil
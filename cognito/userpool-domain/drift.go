@@ -0,0 +1,39 @@
+package userpooldomain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+)
+
+// Detect takes ctx, cfg, properties and physicalID and compares the declared Domain against
+// the live domain in Cognito by using the same lookup as the handler. The domain is found
+// by its name, so physicalID isn't needed.
+// Returns *drift.Result and error.
+func Detect(ctx context.Context, cfg aws.Config, properties json.RawMessage, physicalID string) (*drift.Result, error) {
+	c := &config{
+		svc:                awsclient.FromConfig(ctx, cfg, ResourceType).CognitoIdentityProvider(),
+		resourceProperties: &Domain{},
+	}
+
+	if err := json.Unmarshal(properties, c.resourceProperties); err != nil {
+		return nil, fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+
+	domain, err := c.getDomain(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain == nil {
+		return drift.Deleted(), nil
+	}
+
+	// The records aren't settings of the domain, so they never drift.
+	domain.HostedZoneID, domain.RecordName = c.resourceProperties.HostedZoneID, c.resourceProperties.RecordName
+	return drift.Compare(c.resourceProperties, domain), nil
+}
@@ -1,32 +1,36 @@
-package main
+// Package userpooldomain handles the Custom::CognitoUserPoolDomain resource.
+package userpooldomain
 
 import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 	l "github.com/nuttmeister/llogger"
 
 	// External - AWS
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
 )
 
 // http client timeout in seconds.
 const (
 	service      = "custom-cf"
 	function     = "userpool-domain"
-	resourceType = "Custom::CognitoUserPoolDomain"
+	ResourceType = "Custom::CognitoUserPoolDomain"
 	httpTimeout  = 30
 )
 
 type config struct {
 	log *l.Client
 	svc *cognitoidentityprovider.CognitoIdentityProvider
+	dns *route53.Route53
 
 	physicalID            string  // The physical ID to use for the resource.
 	resourceProperties    *Domain // The new resource data from the template.
@@ -35,60 +39,81 @@ type config struct {
 
 // Domain contains the fields for creating a UserPool Domain.
 type Domain struct {
-	Domain             string              `json:"Domain"`
-	CustomDomainConfig *CustomDomainConfig `json:"CustomDomainConfig,omitempty"`
-	UserPoolID         string              `json:"UserPoolId"`
+	cloudFrontDomain string
+
+	Domain             string              `json:"Domain" cfn:"required,createOnly" doc:"Domain name or part of the domain name to use"`
+	CustomDomainConfig *CustomDomainConfig `json:"CustomDomainConfig,omitempty" doc:"Configuration for a custom domain. Required if a custom domain is used"`
+	UserPoolID         string              `json:"UserPoolId" cfn:"required" doc:"The ID of the UserPool to create the Domain in"`
+
+	// Route 53, these aren't settings of the domain itself.
+	HostedZoneID string `json:"HostedZoneId,omitempty" doc:"ID of a Route 53 hosted zone to create A and AAAA alias records to the CloudFront domain in. Requires CustomDomainConfig"`
+	RecordName   string `json:"RecordName,omitempty" doc:"Name of the alias records. Requires HostedZoneId, defaults to Domain"`
 }
 
 // CustomDomainConfig contains the custom domain configuration.
 type CustomDomainConfig struct {
-	CertificateArn string `json:"CertificateArn"`
-}
-
-func main() {
-	lambda.Start(handler)
+	CertificateArn string `json:"CertificateArn" cfn:"required" doc:"ARN of the ACM certificate in us-east-1 to use for the custom domain"`
 }
 
-// handler takes context.Context and *events.Request.
+// Handler takes context.Context and *events.Request.
 // Returns error.
-func handler(ctx context.Context, req *events.Request) error {
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
 	// Create the config.
 	c := createConfig(ctx, req)
 	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
 	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
 
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
 	// Create the Cognit service.
-	if err := c.createCognitoService(); err != nil {
-		return c.runError(req, err)
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
 	}
 
 	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
 	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Set physical ID
-	c.physicalID = fmt.Sprintf("%s", c.resourceProperties.Domain)
+	c.physicalID = awsconfig.PhysicalID(req, c.resourceProperties.Domain)
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
 
 	// create, update or delete the userpool federation.
-	data, err := c.run(req)
+	data, err := c.run(awsCtx, req)
 	if err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Send the result to the pre-signed s3 url.
-	if err := req.Send(c.physicalID, data, err); err != nil {
+	if err := req.SendWithContext(ctx, c.physicalID, data, err); err != nil {
 		return err
 	}
 	return nil
 }
 
 // runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
 // Returns error.
-func (c *config) runError(req *events.Request, err error) error {
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
 	if err != nil {
 		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
-		if err := req.Send(c.physicalID, nil, err); err != nil {
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
 			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
 		}
 	}
@@ -109,6 +134,7 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 			"requestId":             req.RequestID,
 			"resourceType":          req.ResourceType,
 			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
 			"resourceProperties":    req.ResourceProperties,
 			"oldResourceProperties": req.OldResourceProperties,
 		}),
@@ -118,15 +144,16 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 	}
 }
 
-// Creates AWS Config and the CognitoIdentity Service.
+// Creates the CognitoIdentity and Route 53 Services for the target account and region in req.
 // Returns error.
-func (c *config) createCognitoService() error {
-	cfg, err := external.LoadDefaultAWSConfig()
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
 	if err != nil {
-		return fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+		return err
 	}
 
-	c.svc = cognitoidentityprovider.New(cfg)
+	c.svc = clients.CognitoIdentityProvider()
+	c.dns = clients.Route53()
 	return nil
 }
 
@@ -134,14 +161,15 @@ func (c *config) createCognitoService() error {
 // If the domain already exists in the user pool it will be adopted into the
 // cf stack. This so that manually created domains don't have to be recreated.
 // Returns map[string]string and error.
-func (c *config) run(req *events.Request) (map[string]string, error) {
+func (c *config) run(ctx context.Context, req *events.Request) (map[string]string, error) {
 	// Check for the correct ResourceType
-	if req.ResourceType != resourceType {
-		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", resourceType, req.ResourceType)
+	if req.ResourceType != ResourceType {
+		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
 	}
 
-	// Check if the Domain already exists.
-	domain, err := c.getDomain(false)
+	// Check if the Domain already exists. It still belongs to the old UserPool
+	// if only UserPoolId was changed, unless it's moved to another account or region.
+	domain, err := c.getDomain(ctx, req.RequestType == "Update" && !movesTarget(req) && c.movesDomain())
 	if err != nil {
 		return nil, err
 	}
@@ -153,13 +181,22 @@ func (c *config) run(req *events.Request) (map[string]string, error) {
 
 	// If Delete is run on the stack.
 	case req.RequestType == "Delete" && domain != nil:
-		return nil, c.deleteDomain(req, false)
+		if err := c.deleteRecords(ctx, c.resourceProperties, domain.cloudFrontDomain); err != nil {
+			return nil, err
+		}
+		return nil, c.deleteDomain(ctx, req, false)
 
 	// If Update is run on the stack but the domain doesn't exist
 	// create it. If it was a resource that needed replacement a delete event
 	// will be sent on the old resource once the new one has been created.
 	case req.RequestType == "Update" && domain == nil:
-		oldDomain, err := c.getDomain(true)
+		// The old domain is in another account or region, CloudFormation deletes it
+		// through its old physical ID.
+		if movesTarget(req) {
+			return c.createDomain(ctx, req)
+		}
+
+		oldDomain, err := c.getDomain(ctx, true)
 		if err != nil {
 			return nil, err
 		}
@@ -167,36 +204,52 @@ func (c *config) run(req *events.Request) (map[string]string, error) {
 		// If oldDomain is nil, the old domain has already been deleted.
 		// So just create the new one.
 		if oldDomain == nil {
-			return c.createDomain(req)
+			return c.createDomain(ctx, req)
 		}
 		// Update the domain.
-		return c.updateDomain(req)
+		return c.updateDomain(ctx, req, oldDomain)
 
 	// If Update is run on the stack.
 	case req.RequestType == "Update" && domain != nil:
-		_, err := c.getDomain(true)
-		if err != nil {
-			return nil, err
-		}
-		return c.updateDomain(req)
+		return c.updateDomain(ctx, req, domain)
 
 	// If Create is run on the stack but the domain doesn't exist.
 	case req.RequestType == "Create" && domain == nil:
-		return c.createDomain(req)
+		return c.createDomain(ctx, req)
 
 	// If Create is run on the stack and the domain exists, adopt and update it.
 	case req.RequestType == "Create" && domain != nil:
-		return c.updateDomain(req)
+		return c.updateDomain(ctx, req, domain)
 	}
 
 	return nil, fmt.Errorf("Didn't get RequestType Create, Update or Delete")
 }
 
+// movesDomain returns true if an Update moves the domain to another UserPool.
+// Returns bool.
+func (c *config) movesDomain() bool {
+	old, new := c.oldResourceProperties, c.resourceProperties
+	return old.Domain == new.Domain && old.UserPoolID != new.UserPoolID
+}
+
+// movesTarget takes req and returns true if an Update moves the domain to another account
+// or region, which gives it another physical ID.
+// Returns bool.
+func movesTarget(req *events.Request) bool {
+	if req.RequestType != "Update" {
+		return false
+	}
+
+	old := *req
+	old.ResourceProperties = req.OldResourceProperties
+	return awsconfig.PhysicalID(&old, "") != awsconfig.PhysicalID(req, "")
+}
+
 // getDomain will get the domain with the domain specified i c.resourceProperties.Domain
 // or c.oldResourceProperties.Domain depending on if old is true or false.
 // If nil is returned no domain by that name was found.
 // Return *Domain and error.
-func (c *config) getDomain(old bool) (*Domain, error) {
+func (c *config) getDomain(ctx context.Context, old bool) (*Domain, error) {
 	props := c.resourceProperties
 	if old {
 		props = c.oldResourceProperties
@@ -210,23 +263,21 @@ func (c *config) getDomain(old bool) (*Domain, error) {
 		return nil, nil
 	}
 
-	resp, err := c.svc.DescribeUserPoolDomainRequest(
+	r := c.svc.DescribeUserPoolDomainRequest(
 		&cognitoidentityprovider.DescribeUserPoolDomainInput{
 			Domain: &props.Domain,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
 		// If the domain doesn't exists. Return nil and no error.
-		if strings.Contains(err.Error(), "does not exist") {
+		if awserrors.IsNotFound(err) {
 			return nil, nil
 		}
 
 		return nil, err
 	}
 
-	if resp != nil {
-		fmt.Printf("%+v", *resp)
-	}
-
 	// If domain is nil, the domain doesn't exists.
 	if resp.DomainDescription.Domain == nil {
 		return nil, nil
@@ -238,11 +289,13 @@ func (c *config) getDomain(old bool) (*Domain, error) {
 	}
 
 	domain := &Domain{
-		UserPoolID: *resp.DomainDescription.UserPoolId,
+		Domain:           *resp.DomainDescription.Domain,
+		UserPoolID:       *resp.DomainDescription.UserPoolId,
+		cloudFrontDomain: aws.StringValue(resp.DomainDescription.CloudFrontDistribution),
 	}
 
 	// Only set CustomDomainConfig if it's not nil.
-	if resp.DomainDescription.CustomDomainConfig.CertificateArn != nil {
+	if resp.DomainDescription.CustomDomainConfig != nil && resp.DomainDescription.CustomDomainConfig.CertificateArn != nil {
 		domain.CustomDomainConfig = &CustomDomainConfig{CertificateArn: *resp.DomainDescription.CustomDomainConfig.CertificateArn}
 	}
 
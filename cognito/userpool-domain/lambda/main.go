@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	userpooldomain "github.com/dwtechnologies/custom-cf/cognito/userpool-domain"
+)
+
+func main() {
+	lambda.Start(userpooldomain.Handler)
+}
@@ -0,0 +1,153 @@
+package userpooldomain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	l "github.com/nuttmeister/llogger"
+)
+
+// cloudFrontZoneID is the hosted zone of every CloudFront distribution, which alias records point to.
+const cloudFrontZoneID = "Z2FDTNDATAQYW2"
+
+// How often Route 53 is asked if a change is INSYNC.
+const recordWaitDelay = 5 * time.Second
+
+// recordTypes are the types of the alias records, for IPv4 and IPv6.
+var recordTypes = []route53.RRType{route53.RRTypeA, route53.RRTypeAaaa}
+
+// recordName returns the name of the alias records of d, which defaults to the domain.
+// Returns string.
+func (d *Domain) recordName() string {
+	if d.RecordName != "" {
+		return d.RecordName
+	}
+	return d.Domain
+}
+
+// upsertRecords takes cloudFrontDomain and points the alias records of the domain to it,
+// then waits until Route 53 has the records on all of its name servers.
+// Nothing is done if HostedZoneId isn't set.
+// Returns error.
+func (c *config) upsertRecords(ctx context.Context, cloudFrontDomain string) error {
+	props := c.resourceProperties
+	switch {
+	case props.HostedZoneID == "":
+		return nil
+
+	case cloudFrontDomain == "":
+		return fmt.Errorf("Couldn't create the records for Domain %s, Cognito didn't return its CloudFront domain", props.Domain)
+	}
+
+	name := props.recordName()
+	changes := []route53.Change{}
+	for _, typ := range recordTypes {
+		changes = append(changes, route53.Change{
+			Action: route53.ChangeActionUpsert,
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name: &name,
+				Type: typ,
+				AliasTarget: &route53.AliasTarget{
+					DNSName:              &cloudFrontDomain,
+					HostedZoneId:         aws.String(cloudFrontZoneID),
+					EvaluateTargetHealth: aws.Bool(false),
+				},
+			},
+		})
+	}
+
+	r := c.dns.ChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: &props.HostedZoneID,
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String(fmt.Sprintf("Cognito UserPool Domain %s, managed by %s", props.Domain, ResourceType)),
+			Changes: changes,
+		},
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return fmt.Errorf("Failed to create the records %s in HostedZone %s. Error %w", name, props.HostedZoneID, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Waiting for the records %s to %s to be INSYNC", name, cloudFrontDomain)})
+
+	// The deadline of ctx stops the waiter, so it has no limit on the number of attempts.
+	err = c.dns.WaitUntilResourceRecordSetsChangedWithContext(ctx,
+		&route53.GetChangeInput{Id: resp.ChangeInfo.Id},
+		aws.WithWaiterDelay(aws.ConstantWaiterDelay(recordWaitDelay)),
+		aws.WithWaiterMaxAttempts(0),
+	)
+	if err != nil {
+		return fmt.Errorf("Failed waiting for the records %s in HostedZone %s to be INSYNC. Error %w", name, props.HostedZoneID, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Records %s point to %s", name, cloudFrontDomain)})
+	return nil
+}
+
+// deleteRecords takes props and cloudFrontDomain and deletes the alias records of props.
+// Only records that still point to cloudFrontDomain are deleted, so records that were
+// changed to point elsewhere are kept. Nothing is done if HostedZoneId isn't set.
+// Returns error.
+func (c *config) deleteRecords(ctx context.Context, props *Domain, cloudFrontDomain string) error {
+	if props.HostedZoneID == "" || cloudFrontDomain == "" {
+		return nil
+	}
+
+	name := props.recordName()
+	r := c.dns.ListResourceRecordSetsRequest(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    &props.HostedZoneID,
+		StartRecordName: &name,
+		MaxItems:        aws.String("10"),
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return fmt.Errorf("Couldn't get the records %s in HostedZone %s. Error %w", name, props.HostedZoneID, err)
+	}
+
+	changes := []route53.Change{}
+	for i := range resp.ResourceRecordSets {
+		set := resp.ResourceRecordSets[i]
+		switch {
+		case !sameName(aws.StringValue(set.Name), name):
+			continue
+
+		case set.Type != route53.RRTypeA && set.Type != route53.RRTypeAaaa:
+			continue
+
+		case set.AliasTarget == nil || !sameName(aws.StringValue(set.AliasTarget.DNSName), cloudFrontDomain):
+			c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Keeping record %s %s since it doesn't point to %s", name, set.Type, cloudFrontDomain)})
+			continue
+		}
+
+		changes = append(changes, route53.Change{Action: route53.ChangeActionDelete, ResourceRecordSet: &set})
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	d := c.dns.ChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: &props.HostedZoneID,
+		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+	})
+	d.SetContext(ctx)
+	if _, err := d.Send(); err != nil {
+		return fmt.Errorf("Failed to delete the records %s in HostedZone %s. Error %w", name, props.HostedZoneID, err)
+	}
+
+	c.log.Print(l.Input{"loglevel": "info", "message": fmt.Sprintf("Deleted the records %s in HostedZone %s", name, props.HostedZoneID)})
+	return nil
+}
+
+// sameName takes a and b and returns true if they are the same DNS name. Route 53 returns
+// names fully qualified and in lower case.
+// Returns bool.
+func sameName(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
@@ -0,0 +1,163 @@
+package userpooldomain
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+// testCloudFrontDomain is the CloudFront domain that Cognito serves the test domain from.
+const testCloudFrontDomain = "d111111abcdef8.cloudfront.net"
+
+// recordProperties are the properties of a custom domain with records in a hosted zone.
+var recordProperties = map[string]interface{}{
+	"Domain":             "auth.example.com",
+	"UserPoolId":         "eu-west-1_abc",
+	"CustomDomainConfig": map[string]string{"CertificateArn": "arn:aws:acm:us-east-1:123456789012:certificate/abc"},
+	"HostedZoneId":       "Z123",
+}
+
+// changeBatch is the XML input of ChangeResourceRecordSets.
+type changeBatch struct {
+	Changes []struct {
+		Action      string `xml:"Action"`
+		Name        string `xml:"ResourceRecordSet>Name"`
+		Type        string `xml:"ResourceRecordSet>Type"`
+		DNSName     string `xml:"ResourceRecordSet>AliasTarget>DNSName"`
+		AliasZoneID string `xml:"ResourceRecordSet>AliasTarget>HostedZoneId"`
+	} `xml:"ChangeBatch>Changes>Change"`
+}
+
+// changes takes kit and returns the changes of the last ChangeResourceRecordSets as
+// "Action Type Name DNSName HostedZoneId".
+// Returns []string.
+func changes(t *testing.T, kit *testkit.Kit) []string {
+	batch := &changeBatch{}
+	if err := xml.Unmarshal(kit.Call("ChangeResourceRecordSets").Body, batch); err != nil {
+		t.Fatalf("Couldn't unmarshal the input of ChangeResourceRecordSets. Error %s", err.Error())
+	}
+
+	l := []string{}
+	for _, change := range batch.Changes {
+		l = append(l, fmt.Sprintf("%s %s %s %s %s", change.Action, change.Type, change.Name, change.DNSName, change.AliasZoneID))
+	}
+	return l
+}
+
+// Test that the alias records are pointed to the CloudFront domain of the new domain
+// and that the function waits for them to be INSYNC.
+func TestUpsertRecords(t *testing.T) {
+	tests := []struct {
+		recordName string
+		want       []string
+	}{
+		{
+			want: []string{
+				"UPSERT A auth.example.com " + testCloudFrontDomain + " " + cloudFrontZoneID,
+				"UPSERT AAAA auth.example.com " + testCloudFrontDomain + " " + cloudFrontZoneID,
+			},
+		},
+		{
+			recordName: "login.example.com",
+			want: []string{
+				"UPSERT A login.example.com " + testCloudFrontDomain + " " + cloudFrontZoneID,
+				"UPSERT AAAA login.example.com " + testCloudFrontDomain + " " + cloudFrontZoneID,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("DescribeUserPoolDomain", `{"DomainDescription":{}}`)
+		kit.Respond("CreateUserPoolDomain", `{"CloudFrontDomain":"`+testCloudFrontDomain+`"}`)
+		kit.Respond("ChangeResourceRecordSets", "<ChangeInfo><Id>/change/C123</Id><Status>PENDING</Status><SubmittedAt>2019-01-22T00:00:00Z</SubmittedAt></ChangeInfo>")
+		kit.Respond("GetChange", "<ChangeInfo><Id>/change/C123</Id><Status>INSYNC</Status><SubmittedAt>2019-01-22T00:00:00Z</SubmittedAt></ChangeInfo>")
+
+		props := map[string]interface{}{"RecordName": test.recordName}
+		for key, val := range recordProperties {
+			props[key] = val
+		}
+
+		resp := kit.Run(Handler, kit.Request(events.RequestCreate, ResourceType, props, nil))
+		switch {
+		case resp.Status != "SUCCESS":
+			t.Errorf("Test number: %d failed. Expected SUCCESS but got %s. Reason %s", i+1, resp.Status, resp.Reason)
+
+		case resp.Data["CloudFrontDistribution"] != testCloudFrontDomain:
+			t.Errorf("Test number: %d failed. Expected CloudFrontDistribution %s but got %v", i+1, testCloudFrontDomain, resp.Data)
+
+		case fmt.Sprint(kit.Calls()) != "[DescribeUserPoolDomain CreateUserPoolDomain ChangeResourceRecordSets GetChange]":
+			t.Errorf("Test number: %d failed. Unexpected calls %v", i+1, kit.Calls())
+
+		case !reflect.DeepEqual(changes(t, kit), test.want):
+			t.Errorf("Test number: %d failed. Wanted changes %q but got %q", i+1, test.want, changes(t, kit))
+		}
+	}
+}
+
+// Test that a Delete only deletes the A and AAAA alias records that still point to the
+// CloudFront domain of the domain, and keeps every other record.
+func TestDeleteRecords(t *testing.T) {
+	tests := []struct {
+		records string
+		calls   string
+		want    []string
+	}{
+		// Route 53 returns the names fully qualified and the alias targets in any case.
+		{
+			records: `<ResourceRecordSet><Name>auth.example.com.</Name><Type>A</Type><AliasTarget><HostedZoneId>Z2FDTNDATAQYW2</HostedZoneId><DNSName>D111111ABCDEF8.cloudfront.net.</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget></ResourceRecordSet>` +
+				`<ResourceRecordSet><Name>auth.example.com.</Name><Type>AAAA</Type><AliasTarget><HostedZoneId>Z2FDTNDATAQYW2</HostedZoneId><DNSName>d111111abcdef8.cloudfront.net</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget></ResourceRecordSet>`,
+			calls: "[DescribeUserPoolDomain ListResourceRecordSets ChangeResourceRecordSets DeleteUserPoolDomain]",
+			want: []string{
+				"DELETE A auth.example.com. D111111ABCDEF8.cloudfront.net. Z2FDTNDATAQYW2",
+				"DELETE AAAA auth.example.com. d111111abcdef8.cloudfront.net Z2FDTNDATAQYW2",
+			},
+		},
+		// Records that point elsewhere, records of other types and the records after it are kept.
+		{
+			records: `<ResourceRecordSet><Name>auth.example.com.</Name><Type>A</Type><AliasTarget><HostedZoneId>Z2FDTNDATAQYW2</HostedZoneId><DNSName>d222222abcdef8.cloudfront.net.</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget></ResourceRecordSet>` +
+				`<ResourceRecordSet><Name>auth.example.com.</Name><Type>AAAA</Type><AliasTarget><HostedZoneId>Z2FDTNDATAQYW2</HostedZoneId><DNSName>d111111abcdef8.cloudfront.net.</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget></ResourceRecordSet>` +
+				`<ResourceRecordSet><Name>auth.example.com.</Name><Type>TXT</Type><TTL>300</TTL><ResourceRecords><ResourceRecord><Value>"verification"</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>` +
+				`<ResourceRecordSet><Name>www.example.com.</Name><Type>A</Type><AliasTarget><HostedZoneId>Z2FDTNDATAQYW2</HostedZoneId><DNSName>d111111abcdef8.cloudfront.net.</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget></ResourceRecordSet>`,
+			calls: "[DescribeUserPoolDomain ListResourceRecordSets ChangeResourceRecordSets DeleteUserPoolDomain]",
+			want: []string{
+				"DELETE AAAA auth.example.com. d111111abcdef8.cloudfront.net. Z2FDTNDATAQYW2",
+			},
+		},
+		// A record that isn't an alias is kept, so nothing is changed.
+		{
+			records: `<ResourceRecordSet><Name>auth.example.com.</Name><Type>A</Type><TTL>300</TTL><ResourceRecords><ResourceRecord><Value>192.0.2.1</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`,
+			calls:   "[DescribeUserPoolDomain ListResourceRecordSets DeleteUserPoolDomain]",
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("DescribeUserPoolDomain", `{"DomainDescription":{"Domain":"auth.example.com","UserPoolId":"eu-west-1_abc","CloudFrontDistribution":"`+testCloudFrontDomain+`"}}`)
+		kit.Respond("ListResourceRecordSets", "<ResourceRecordSets>"+test.records+"</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>10</MaxItems>")
+		kit.Respond("ChangeResourceRecordSets", "<ChangeInfo><Id>/change/C123</Id><Status>PENDING</Status><SubmittedAt>2019-01-22T00:00:00Z</SubmittedAt></ChangeInfo>")
+		kit.Respond("DeleteUserPoolDomain", "")
+
+		req := kit.Request(events.RequestDelete, ResourceType, recordProperties, nil)
+		req.PhysicalResourceID = "auth.example.com"
+
+		resp := kit.Run(Handler, req)
+		switch {
+		case resp.Status != "SUCCESS":
+			t.Errorf("Test number: %d failed. Expected SUCCESS but got %s. Reason %s", i+1, resp.Status, resp.Reason)
+
+		case fmt.Sprint(kit.Calls()) != test.calls:
+			t.Errorf("Test number: %d failed. Wanted calls %s but got %v", i+1, test.calls, kit.Calls())
+
+		case kit.Call("ListResourceRecordSets").Params.Get("name") != "auth.example.com":
+			t.Errorf("Test number: %d failed. Expected the records to be listed from auth.example.com", i+1)
+
+		case test.want != nil && !reflect.DeepEqual(changes(t, kit), test.want):
+			t.Errorf("Test number: %d failed. Wanted changes %q but got %q", i+1, test.want, changes(t, kit))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package userpooldomain
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito UserPool Domain CloudFormation Support",
+	Properties:  Domain{},
+	// Waiting for the records to be INSYNC can take minutes, and the domain may be recreated first.
+	Timeout: 300,
+	Attributes: []spec.Attribute{
+		{Name: "Domain", Description: "The CloudFront domain of the domain, same as CloudFrontDistribution"},
+		{Name: "CloudFrontDistribution", Description: "The CloudFront distribution that serves the domain, the target of its alias records"},
+	},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-idp:CreateUserPoolDomain",
+				"cognito-idp:DeleteUserPoolDomain",
+				"cognito-idp:UpdateUserPoolDomain",
+			},
+			Resources: []string{"arn:aws:cognito-idp:*:${AWS::AccountId}:userpool/*"},
+		},
+		{
+			Comment: "These actions don't support resource-level permissions.",
+			Actions: []string{
+				"cognito-idp:DescribeUserPoolDomain",
+			},
+			Resources: []string{"*"},
+		},
+		{
+			Comment: "Only used when the HostedZoneId property is set on the custom resource.",
+			Actions: []string{
+				"route53:ChangeResourceRecordSets",
+				"route53:ListResourceRecordSets",
+			},
+			Resources: []string{"arn:aws:route53:::hostedzone/*"},
+		},
+		{
+			Comment:   "Called by the AWS SDK waiter that waits for the records to be INSYNC.",
+			Actions:   []string{"route53:GetChange"},
+			Resources: []string{"arn:aws:route53:::change/*"},
+			Implicit:  true,
+		},
+		{
+			Comment:   "Not called by the function itself, kept for custom domains.",
+			Actions:   []string{"cloudfront:ListDistributions"},
+			Resources: []string{"*"},
+			Implicit:  true,
+		},
+	},
+}
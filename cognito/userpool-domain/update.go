@@ -1,31 +1,124 @@
-package main
+package userpooldomain
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/dwtechnologies/custom-cf/lib/events"
+	l "github.com/nuttmeister/llogger"
 )
 
-// updateDomain updates the domain specified in req.
+// The ways that updateDomain can update a domain.
+const (
+	planNone        = "none"        // Nothing has changed.
+	planCertificate = "certificate" // Only the certificate has changed, the domain is updated in place.
+	planRecreate    = "recreate"    // The domain or UserPool has changed, the domain is deleted and created.
+)
+
+// updateDomain updates the domain specified in req. live is the domain as it is in Cognito,
+// which is the old domain if the domain is changed.
+// Deleting and creating a domain takes the hosted UI offline for up to an hour, so it's
+// only done when the domain or UserPool changes. A new certificate is updated in place.
 // Returns map[string]string and error.
-func (c *config) updateDomain(req *events.Request) (map[string]string, error) {
-	switch {
-	case c.resourceProperties.Domain == "":
-		return nil, fmt.Errorf("No Domain specified")
+func (c *config) updateDomain(ctx context.Context, req *events.Request, live *Domain) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
 
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("No UserPoolId specified")
+	// An adopted domain has no old properties, so it's compared to the live domain.
+	// So is a domain that already exists in the account or region it's moved to.
+	moved := movesTarget(req)
+	old := c.oldResourceProperties
+	if req.RequestType == "Create" || moved {
+		old = live
+	}
 
-	case c.oldResourceProperties.Domain == "":
+	switch {
+	case old.Domain == "":
 		return nil, fmt.Errorf("No Old Domain specified")
 
-	case c.oldResourceProperties.UserPoolID == "":
+	case old.UserPoolID == "":
 		return nil, fmt.Errorf("No Old UserPoolId specified")
 	}
 
-	// Due to the API for UserPool Domain being so buggy we need to delete and create.
-	if err := c.deleteDomain(req, true); err != nil {
-		return nil, err
+	plan := c.plan(old, live)
+	c.log.Print(l.Input{"loglevel": "info", "plan": plan, "message": fmt.Sprintf("Updating Domain %s with plan %s", c.resourceProperties.Domain, plan)})
+
+	data := domainData(live.cloudFrontDomain)
+	switch plan {
+	case planRecreate:
+		if err := c.deleteDomain(ctx, req, true); err != nil {
+			return nil, err
+		}
+
+		// createDomain creates the records as well.
+		created, err := c.createDomain(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		data = created
+
+	case planCertificate:
+		updated, err := c.updateCertificate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data = updated
+		fallthrough
+
+	default:
+		if err := c.upsertRecords(ctx, data["CloudFrontDistribution"]); err != nil {
+			return nil, err
+		}
+	}
+
+	// The old records are no longer managed if HostedZoneId or RecordName was changed.
+	// The Delete of the old physical ID removes them if the domain was moved.
+	if old, new := c.oldResourceProperties, c.resourceProperties; !moved && (old.HostedZoneID != new.HostedZoneID || old.recordName() != new.recordName()) {
+		if err := c.deleteRecords(ctx, old, live.cloudFrontDomain); err != nil {
+			return nil, err
+		}
 	}
-	return c.createDomain(req)
+
+	return data, nil
+}
+
+// plan takes old and live and returns how the domain needs to be updated.
+// Returns string.
+func (c *config) plan(old *Domain, live *Domain) string {
+	new := c.resourceProperties
+
+	switch {
+	case old.Domain != new.Domain, old.UserPoolID != new.UserPoolID:
+		return planRecreate
+
+	// A custom domain can't become a prefix domain, or the other way around, in place.
+	case (live.CustomDomainConfig == nil) != (new.CustomDomainConfig == nil):
+		return planRecreate
+
+	case new.CustomDomainConfig != nil && (live.CustomDomainConfig == nil || live.CustomDomainConfig.CertificateArn != new.CustomDomainConfig.CertificateArn):
+		return planCertificate
+	}
+	return planNone
+}
+
+// updateCertificate updates the certificate of the custom domain in place.
+// Returns map[string]string and error.
+func (c *config) updateCertificate(ctx context.Context) (map[string]string, error) {
+	r := c.svc.UpdateUserPoolDomainRequest(&cognitoidentityprovider.UpdateUserPoolDomainInput{
+		Domain:     &c.resourceProperties.Domain,
+		UserPoolId: &c.resourceProperties.UserPoolID,
+		CustomDomainConfig: &cognitoidentityprovider.CustomDomainConfigType{
+			CertificateArn: &c.resourceProperties.CustomDomainConfig.CertificateArn,
+		},
+	})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to update the certificate of Domain. Error %w", err)
+	}
+
+	return domainData(aws.StringValue(resp.CloudFrontDomain)), nil
 }
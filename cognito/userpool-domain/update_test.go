@@ -0,0 +1,126 @@
+package userpooldomain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/lib/testkit"
+)
+
+// Test that the domain is only deleted and created when it can't be updated in place.
+func TestPlan(t *testing.T) {
+	certificate := &CustomDomainConfig{CertificateArn: "arn:aws:acm:us-east-1:123456789012:certificate/abc"}
+	renewed := &CustomDomainConfig{CertificateArn: "arn:aws:acm:us-east-1:123456789012:certificate/def"}
+
+	tests := []struct {
+		old  *Domain
+		live *Domain
+		new  *Domain
+		want string
+	}{
+		// Nothing that Cognito has was changed.
+		{
+			old:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			live: &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			new:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate, HostedZoneID: "Z123"},
+			want: planNone,
+		},
+		// A new certificate.
+		{
+			old:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			live: &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			new:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: renewed},
+			want: planCertificate,
+		},
+		// The certificate was changed outside of the stack.
+		{
+			old:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: renewed},
+			live: &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			new:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: renewed},
+			want: planCertificate,
+		},
+		// A new domain.
+		{
+			old:  &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			live: &Domain{Domain: "auth.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			new:  &Domain{Domain: "login.example.com", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			want: planRecreate,
+		},
+		// A new UserPool.
+		{
+			old:  &Domain{Domain: "auth-example", UserPoolID: "eu-west-1_abc"},
+			live: &Domain{Domain: "auth-example", UserPoolID: "eu-west-1_abc"},
+			new:  &Domain{Domain: "auth-example", UserPoolID: "eu-west-1_def"},
+			want: planRecreate,
+		},
+		// A custom domain that becomes a prefix domain.
+		{
+			old:  &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			live: &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			new:  &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc"},
+			want: planRecreate,
+		},
+		// A prefix domain that becomes a custom domain.
+		{
+			old:  &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc"},
+			live: &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc"},
+			new:  &Domain{Domain: "auth", UserPoolID: "eu-west-1_abc", CustomDomainConfig: certificate},
+			want: planRecreate,
+		},
+	}
+
+	for i, test := range tests {
+		c := &config{resourceProperties: test.new}
+		if got := c.plan(test.old, test.live); got != test.want {
+			t.Errorf("Test number: %d failed. Wanted plan %s but got %s", i+1, test.want, got)
+		}
+	}
+}
+
+// Test that a domain moved to another region is created or adopted there, and that the old
+// domain is left to the Delete of its old physical ID.
+func TestUpdateTarget(t *testing.T) {
+	tests := []struct {
+		old      map[string]string
+		new      map[string]string
+		describe string
+		calls    string
+	}{
+		// The domain already exists in the new region, so it's adopted.
+		{
+			old:      map[string]string{"Domain": "auth-example", "UserPoolId": "eu-west-1_abc", "Region": "eu-west-1"},
+			new:      map[string]string{"Domain": "auth-example", "UserPoolId": "eu-west-1_abc", "Region": "eu-north-1"},
+			describe: `{"DomainDescription":{"Domain":"auth-example","UserPoolId":"eu-west-1_abc"}}`,
+			calls:    "[DescribeUserPoolDomain]",
+		},
+		// The domain is created in the UserPool of the new region.
+		{
+			old:      map[string]string{"Domain": "auth-example", "UserPoolId": "eu-west-1_abc", "Region": "eu-west-1"},
+			new:      map[string]string{"Domain": "auth-example", "UserPoolId": "eu-north-1_def", "Region": "eu-north-1"},
+			describe: `{"DomainDescription":{}}`,
+			calls:    "[DescribeUserPoolDomain CreateUserPoolDomain]",
+		},
+	}
+
+	for i, test := range tests {
+		kit := testkit.New(t)
+		kit.Respond("DescribeUserPoolDomain", test.describe)
+		kit.Respond("CreateUserPoolDomain", `{}`)
+
+		req := kit.Request(events.RequestUpdate, ResourceType, test.new, test.old)
+		req.PhysicalResourceID = "auth-example-eu-west-1"
+
+		resp := kit.Run(Handler, req)
+		switch {
+		case resp.Status != "SUCCESS":
+			t.Errorf("Test number: %d failed. Expected SUCCESS but got %s. Reason %s", i+1, resp.Status, resp.Reason)
+
+		case resp.PhysicalResourceID != "auth-example-eu-north-1":
+			t.Errorf("Test number: %d failed. Expected physical ID auth-example-eu-north-1 but got %s", i+1, resp.PhysicalResourceID)
+
+		case fmt.Sprint(kit.Calls()) != test.calls:
+			t.Errorf("Test number: %d failed. Wanted calls %s but got %v", i+1, test.calls, kit.Calls())
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package userpooldomain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &Domain{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the settings of the domain.
+// Returns error.
+func (d *Domain) validate() error {
+	switch {
+	case d.Domain == "":
+		return spec.Errorf("Domain", "No Domain specified")
+
+	case d.UserPoolID == "":
+		return spec.Errorf("UserPoolId", "No UserPoolId specified")
+
+	case d.HostedZoneID != "" && d.CustomDomainConfig == nil:
+		return spec.Errorf("HostedZoneId", "HostedZoneId requires CustomDomainConfig, only custom domains can have records")
+
+	case d.RecordName != "" && d.HostedZoneID == "":
+		return spec.Errorf("RecordName", "RecordName requires HostedZoneId to be set")
+	}
+	return nil
+}
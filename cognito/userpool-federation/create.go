@@ -1,6 +1,7 @@
-package main
+package userpoolfederation
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -11,30 +12,24 @@ import (
 // settings specified by req. If the user pool already exists it will be updated with
 // the settings in req.
 // Returns error.
-func (c *config) createIdentityProvider(req *events.Request) (map[string]string, error) {
-	switch {
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("No UserPool ID specified")
-
-	case c.resourceProperties.ProviderName == "":
-		return nil, fmt.Errorf("No Identity Provider Name specified")
+func (c *config) createIdentityProvider(ctx context.Context, req *events.Request) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.svc.CreateIdentityProviderRequest(
+	r := c.svc.CreateIdentityProviderRequest(
 		&cognitoidentityprovider.CreateIdentityProviderInput{
 			ProviderName:     &c.resourceProperties.ProviderName,
 			ProviderType:     cognitoidentityprovider.IdentityProviderTypeType(c.resourceProperties.ProviderType),
 			UserPoolId:       &c.resourceProperties.UserPoolID,
 			ProviderDetails:  c.resourceProperties.ProviderDetails,
 			AttributeMapping: c.resourceProperties.AttributeMapping,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create Identity Provider. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to create Identity Provider. Error %w", err)
 	}
 
-	return map[string]string{
-		"ProviderName": *resp.IdentityProvider.ProviderName,
-		"ProviderType": string(resp.IdentityProvider.ProviderType),
-		"UserPoolId":   *resp.IdentityProvider.UserPoolId,
-	}, nil
+	return providerData(resp.IdentityProvider)
 }
@@ -0,0 +1,27 @@
+package userpoolfederation
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// providerAttributes is the identity provider as it can be accessed with Fn::GetAtt.
+// IdpIdentifiers are joined with commas and can be split with Fn::Split.
+type providerAttributes struct {
+	ProviderName   string   `json:"ProviderName"`
+	ProviderType   string   `json:"ProviderType"`
+	UserPoolID     string   `json:"UserPoolId"`
+	IdpIdentifiers []string `json:"IdpIdentifiers"`
+}
+
+// providerData takes provider as returned by Cognito and returns its attributes.
+// Returns map[string]string and error.
+func providerData(provider *cognitoidentityprovider.IdentityProviderType) (map[string]string, error) {
+	return events.Flatten(&providerAttributes{
+		ProviderName:   aws.StringValue(provider.ProviderName),
+		ProviderType:   string(provider.ProviderType),
+		UserPoolID:     aws.StringValue(provider.UserPoolId),
+		IdpIdentifiers: provider.IdpIdentifiers,
+	})
+}
@@ -1,6 +1,7 @@
-package main
+package userpoolfederation
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -9,7 +10,7 @@ import (
 
 // deleteIdentityProvider will delete the Identity Provider specified in req.
 // Returns error.
-func (c *config) deleteIdentityProvider(req *events.Request) error {
+func (c *config) deleteIdentityProvider(ctx context.Context, req *events.Request) error {
 	// We will get an empty delete event if resource creation fails.
 	// So we need to return nil on these events.
 	switch {
@@ -20,13 +21,15 @@ func (c *config) deleteIdentityProvider(req *events.Request) error {
 		return nil
 	}
 
-	_, err := c.svc.DeleteIdentityProviderRequest(
+	r := c.svc.DeleteIdentityProviderRequest(
 		&cognitoidentityprovider.DeleteIdentityProviderInput{
 			ProviderName: &c.resourceProperties.ProviderName,
 			UserPoolId:   &c.resourceProperties.UserPoolID,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to delete Identity Provider. Error %s", err.Error())
+		return fmt.Errorf("Failed to delete Identity Provider. Error %w", err)
 	}
 
 	return nil
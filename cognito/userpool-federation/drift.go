@@ -0,0 +1,36 @@
+package userpoolfederation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+)
+
+// Detect takes ctx, cfg, properties and physicalID and compares the declared IdentityProvider
+// against the live identity provider in Cognito by using the same lookup as the handler. The
+// identity provider is found by its name, so physicalID isn't needed.
+// Returns *drift.Result and error.
+func Detect(ctx context.Context, cfg aws.Config, properties json.RawMessage, physicalID string) (*drift.Result, error) {
+	c := &config{
+		svc:                awsclient.FromConfig(ctx, cfg, ResourceType).CognitoIdentityProvider(),
+		resourceProperties: &IdentityProvider{},
+	}
+
+	if err := json.Unmarshal(properties, c.resourceProperties); err != nil {
+		return nil, fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+
+	provider, err := c.getIdentityProviderByName(ctx, c.resourceProperties.UserPoolID, c.resourceProperties.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+	}
+
+	if provider == nil {
+		return drift.Deleted(), nil
+	}
+	return drift.Compare(c.resourceProperties, provider), nil
+}
@@ -1,18 +1,19 @@
-package main
+// Package userpoolfederation handles the Custom::CognitoUserPoolFederation resource.
+package userpoolfederation
 
 import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 	l "github.com/nuttmeister/llogger"
 
 	// External - AWS
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 )
 
@@ -20,7 +21,7 @@ import (
 const (
 	service      = "custom-cf"
 	function     = "userpool-federation"
-	resourceType = "Custom::CognitoUserPoolFederation"
+	ResourceType = "Custom::CognitoUserPoolFederation"
 	httpTimeout  = 30
 )
 
@@ -34,60 +35,75 @@ type config struct {
 }
 
 // IdentityProvider valid ProviderTypes are
-// SAML, Facebook, Google, LoginWithAmazon or OIDC
+// SAML, Facebook, Google, LoginWithAmazon, SignInWithApple or OIDC
 type IdentityProvider struct {
 	IdpIdentifiers   []string          `json:"-"`
-	ProviderName     string            `json:"ProviderName"`
-	ProviderType     string            `json:"ProviderType"`
-	ProviderDetails  map[string]string `json:"ProviderDetails"`
-	AttributeMapping map[string]string `json:"AttributeMapping"`
-	UserPoolID       string            `json:"UserPoolId"`
+	ProviderName     string            `json:"ProviderName" cfn:"required,createOnly" doc:"Name of the identity provider"`
+	ProviderType     string            `json:"ProviderType" cfn:"required,enum=SAML|Facebook|Google|LoginWithAmazon|SignInWithApple|OIDC" doc:"The Identity Provider Type"`
+	ProviderDetails  map[string]string `json:"ProviderDetails" cfn:"required" doc:"Details regarding your provider such as **MetadataURL**, **MetadataFile** etc."`
+	AttributeMapping map[string]string `json:"AttributeMapping" doc:"Identity Provider attribute mappings"`
+	UserPoolID       string            `json:"UserPoolId" cfn:"required,createOnly" doc:"The ID of the UserPool to create the Identity Provider in"`
 }
 
-func main() {
-	lambda.Start(handler)
-}
-
-// handler takes context.Context and *events.Request.
+// Handler takes context.Context and *events.Request.
 // Returns error.
-func handler(ctx context.Context, req *events.Request) error {
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
 	// Create the config.
 	c := createConfig(ctx, req)
 	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
 	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
 
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
 	// Create the Cognit service.
-	if err := c.createCognitoService(); err != nil {
-		return c.runError(req, err)
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
 	}
 
 	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
 	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Set physical ID
-	c.physicalID = fmt.Sprintf("%s-%s", c.resourceProperties.UserPoolID, c.resourceProperties.ProviderName)
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s-%s", c.resourceProperties.UserPoolID, c.resourceProperties.ProviderName))
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
 
 	// create, update or delete the userpool federation.
-	data, err := c.run(req)
+	data, err := c.run(awsCtx, req)
 	if err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Send the result to the pre-signed s3 url.
-	if err := req.Send(c.physicalID, data, err); err != nil {
+	if err := req.SendWithContext(ctx, c.physicalID, data, err); err != nil {
 		return err
 	}
 	return nil
 }
 
 // runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
 // Returns error.
-func (c *config) runError(req *events.Request, err error) error {
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
 	if err != nil {
 		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
-		if err := req.Send(c.physicalID, nil, err); err != nil {
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
 			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
 		}
 	}
@@ -108,6 +124,7 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 			"requestId":             req.RequestID,
 			"resourceType":          req.ResourceType,
 			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
 			"resourceProperties":    req.ResourceProperties,
 			"oldResourceProperties": req.OldResourceProperties,
 		}),
@@ -117,15 +134,15 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 	}
 }
 
-// Creates AWS Config and the CognitoIdentity Service.
+// Creates the CognitoIdentity Service for the target account and region in req.
 // Returns error.
-func (c *config) createCognitoService() error {
-	cfg, err := external.LoadDefaultAWSConfig()
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
 	if err != nil {
-		return fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+		return err
 	}
 
-	c.svc = cognitoidentityprovider.New(cfg)
+	c.svc = clients.CognitoIdentityProvider()
 	return nil
 }
 
@@ -133,16 +150,16 @@ func (c *config) createCognitoService() error {
 // If the identity provider already exists in the user pool it will be adopted into the
 // cf stack. This so that manually created identity providers don't have to be recreated.
 // Returns map[string]string and error.
-func (c *config) run(req *events.Request) (map[string]string, error) {
+func (c *config) run(ctx context.Context, req *events.Request) (map[string]string, error) {
 	// Check for the correct ResourceType
-	if req.ResourceType != resourceType {
-		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", resourceType, req.ResourceType)
+	if req.ResourceType != ResourceType {
+		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
 	}
 
 	// Check if the Identity Provider already exists.
-	provider, err := c.getIdentityProviderByName(c.resourceProperties.UserPoolID, c.resourceProperties.ProviderName)
+	provider, err := c.getIdentityProviderByName(ctx, c.resourceProperties.UserPoolID, c.resourceProperties.ProviderName)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
 	}
 
 	switch {
@@ -152,25 +169,25 @@ func (c *config) run(req *events.Request) (map[string]string, error) {
 
 	// If Delete is run on the stack.
 	case req.RequestType == "Delete" && provider != nil:
-		return nil, c.deleteIdentityProvider(req)
+		return nil, c.deleteIdentityProvider(ctx, req)
 
 	// If Update is run on the stack but the Identity Provider doesn't exist.
 	// Create it. If it was a resource that needed replacement a delete event
 	// will be sent on the old resource once the new one has been created.
 	case req.RequestType == "Update" && provider == nil:
-		return c.createIdentityProvider(req)
+		return c.createIdentityProvider(ctx, req)
 
 	// If Update is run on the stack.
 	case req.RequestType == "Update" && provider != nil:
-		return c.updateIdentityProvider(req, provider.IdpIdentifiers)
+		return c.updateIdentityProvider(ctx, req, provider.IdpIdentifiers)
 
 	// If Create is run on the stack but the Identity Provider doesn't exist.
 	case req.RequestType == "Create" && provider == nil:
-		return c.createIdentityProvider(req)
+		return c.createIdentityProvider(ctx, req)
 
 	// If Create is run on the stack and the Identity Provider exists, adopt and update it.
 	case req.RequestType == "Create" && provider != nil:
-		return c.updateIdentityProvider(req, provider.IdpIdentifiers)
+		return c.updateIdentityProvider(ctx, req, provider.IdpIdentifiers)
 	}
 
 	return nil, fmt.Errorf("Didn't get RequestType Create, Update or Delete")
@@ -179,7 +196,7 @@ func (c *config) run(req *events.Request) (map[string]string, error) {
 // getIdentityProviderByName will get the identity provider with providerName on User Pool
 // with poolID. If nil is returned no identity provider by that name was found.
 // Return *IdentityProvider and error.
-func (c *config) getIdentityProviderByName(poolID string, providerName string) (*IdentityProvider, error) {
+func (c *config) getIdentityProviderByName(ctx context.Context, poolID string, providerName string) (*IdentityProvider, error) {
 	// Just return nil, nil if any of the required fields are missing.
 	// Extra validation will be done in the specific resource creation
 	// functions. This is so that Delete on empty will not fail.
@@ -191,14 +208,16 @@ func (c *config) getIdentityProviderByName(poolID string, providerName string) (
 		return nil, nil
 	}
 
-	resp, err := c.svc.DescribeIdentityProviderRequest(
+	r := c.svc.DescribeIdentityProviderRequest(
 		&cognitoidentityprovider.DescribeIdentityProviderInput{
 			UserPoolId:   &poolID,
 			ProviderName: &providerName,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
 		// If the Identity Provier doesn't exists. Return nil and no error.
-		if strings.Contains(err.Error(), "does not exist") {
+		if awserrors.IsNotFound(err) {
 			return nil, nil
 		}
 
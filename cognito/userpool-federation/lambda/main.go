@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	userpoolfederation "github.com/dwtechnologies/custom-cf/cognito/userpool-federation"
+)
+
+func main() {
+	lambda.Start(userpoolfederation.Handler)
+}
@@ -0,0 +1,27 @@
+package userpoolfederation
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito UserPool Federation CloudFormation Support",
+	Properties:  IdentityProvider{},
+	Attributes: []spec.Attribute{
+		{Name: "ProviderName", Description: "The name of the Identity Provider"},
+		{Name: "ProviderType", Description: "The type of the Identity Provider"},
+		{Name: "UserPoolId", Description: "The ID of the UserPool"},
+		{Name: "IdpIdentifiers", Description: "The IdP identifiers of the Identity Provider, joined with commas"},
+	},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-idp:CreateIdentityProvider",
+				"cognito-idp:DeleteIdentityProvider",
+				"cognito-idp:DescribeIdentityProvider",
+				"cognito-idp:UpdateIdentityProvider",
+			},
+			Resources: []string{"arn:aws:cognito-idp:*:${AWS::AccountId}:userpool/*"},
+		},
+	},
+}
@@ -1,6 +1,7 @@
-package main
+package userpoolfederation
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -9,30 +10,24 @@ import (
 
 // updateIdentityProvider updates the identity provider specified by IdpIdentifiers in provider.
 // Returns error.
-func (c *config) updateIdentityProvider(req *events.Request, idps []string) (map[string]string, error) {
-	switch {
-	case c.resourceProperties.UserPoolID == "":
-		return nil, fmt.Errorf("No UserPool ID specified")
-
-	case c.resourceProperties.ProviderName == "":
-		return nil, fmt.Errorf("No Identity Provider Name specified")
+func (c *config) updateIdentityProvider(ctx context.Context, req *events.Request, idps []string) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.svc.UpdateIdentityProviderRequest(
+	r := c.svc.UpdateIdentityProviderRequest(
 		&cognitoidentityprovider.UpdateIdentityProviderInput{
 			IdpIdentifiers:   idps,
 			ProviderName:     &c.resourceProperties.ProviderName,
 			UserPoolId:       &c.resourceProperties.UserPoolID,
 			ProviderDetails:  c.resourceProperties.ProviderDetails,
 			AttributeMapping: c.resourceProperties.AttributeMapping,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to update Identity Provider. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to update Identity Provider. Error %w", err)
 	}
 
-	return map[string]string{
-		"ProviderName": *resp.IdentityProvider.ProviderName,
-		"ProviderType": string(resp.IdentityProvider.ProviderType),
-		"UserPoolId":   *resp.IdentityProvider.UserPoolId,
-	}, nil
+	return providerData(resp.IdentityProvider)
 }
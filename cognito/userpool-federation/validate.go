@@ -0,0 +1,32 @@
+package userpoolfederation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &IdentityProvider{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the settings of the identity provider.
+// Returns error.
+func (idp *IdentityProvider) validate() error {
+	switch {
+	case idp.UserPoolID == "":
+		return spec.Errorf("UserPoolId", "No UserPool ID specified")
+
+	case idp.ProviderName == "":
+		return spec.Errorf("ProviderName", "No Identity Provider Name specified")
+	}
+	return nil
+}
@@ -1,10 +1,14 @@
-package main
+package userpoolmfa
 
-import "github.com/dwtechnologies/custom-cf/lib/events"
+import (
+	"context"
+
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
 
 // deleteMFA will delete the MFA configuration for the UserPool.
 // Returns error.
-func (c *config) deleteMFA(req *events.Request) error {
+func (c *config) deleteMFA(ctx context.Context, req *events.Request) error {
 	// If UserPoolID is empty we must assume that an delete was sent
 	// on a failed resource creation. So just return nil.
 	switch {
@@ -12,5 +16,5 @@ func (c *config) deleteMFA(req *events.Request) error {
 		return nil
 	}
 
-	return c.setMFA(req, true)
+	return c.setMFA(ctx, req, true)
 }
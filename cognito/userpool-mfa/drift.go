@@ -0,0 +1,36 @@
+package userpoolmfa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+)
+
+// Detect takes ctx, cfg, properties and physicalID and compares the declared MFA settings
+// against the live MFA settings of the UserPool. physicalID isn't needed since the settings
+// belong to the UserPool.
+// Returns *drift.Result and error.
+func Detect(ctx context.Context, cfg aws.Config, properties json.RawMessage, physicalID string) (*drift.Result, error) {
+	c := &config{
+		svc:                awsclient.FromConfig(ctx, cfg, ResourceType).CognitoIdentityProvider(),
+		resourceProperties: &MFA{},
+	}
+
+	if err := json.Unmarshal(properties, c.resourceProperties); err != nil {
+		return nil, fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+
+	mfa, err := c.getMFA(ctx, c.resourceProperties.UserPoolID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute API call against Cognito. Error %w", err)
+	}
+
+	if mfa == nil {
+		return drift.Deleted(), nil
+	}
+	return drift.Compare(c.resourceProperties, mfa), nil
+}
@@ -0,0 +1,242 @@
+// Package userpoolmfa handles the Custom::CognitoUserPoolMFA resource.
+package userpoolmfa
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	l "github.com/nuttmeister/llogger"
+
+	// External - AWS
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+)
+
+// http client timeout in seconds.
+const (
+	service      = "custom-cf"
+	function     = "userpool-mfa"
+	ResourceType = "Custom::CognitoUserPoolMFA"
+	httpTimeout  = 30
+)
+
+type config struct {
+	log *l.Client
+	svc *cognitoidentityprovider.CognitoIdentityProvider
+
+	physicalID            string // The physical ID to use for the resource.
+	resourceProperties    *MFA   // The new resource data from the template.
+	oldResourceProperties *MFA   // The old resource data, only on updates.
+}
+
+// MFA contains the fields for setting a UserPools MFA settings.
+type MFA struct {
+	MfaConfiguration              string                         `json:"MfaConfiguration" cfn:"required,enum=OFF|ON|OPTIONAL" doc:"If MFA should be enabled"`
+	SmsMfaConfiguration           *SmsMfaConfiguration           `json:"SmsMfaConfiguration,omitempty" doc:"The SMS configuration if MFA should be via SMS"`
+	SoftwareTokenMfaConfiguration *SoftwareTokenMfaConfiguration `json:"SoftwareTokenMfaConfiguration,omitempty" doc:"The Software Token configuration if MFA should be via software"`
+	UserPoolID                    string                         `json:"UserPoolId" cfn:"required,createOnly" doc:"The ID of the UserPool to configure MFA for"`
+}
+
+// SmsMfaConfiguration contains the SMS MFA configuration.
+type SmsMfaConfiguration struct {
+	SmsAuthenticationMessage string            `json:"SmsAuthenticationMessage" cfn:"required" doc:"SMS message to send for authentication"`
+	SmsConfiguration         *SmsConfiguration `json:"SmsConfiguration" cfn:"required" doc:"Configuration for sending SMS through AWS"`
+}
+
+// SmsConfiguration contains the configuration for sending SMS.
+type SmsConfiguration struct {
+	SnsCallerArn string `json:"SnsCallerArn" cfn:"required" doc:"ARN to the SNS caller"`
+	ExternalID   string `json:"ExternalId" doc:"The external ID"`
+}
+
+// SoftwareTokenMfaConfiguration contains the Software MFA configuration.
+type SoftwareTokenMfaConfiguration struct {
+	Enabled string `json:"Enabled" cfn:"required,type=Boolean" doc:"If Software OTP should be enabled"`
+}
+
+// Handler takes context.Context and *events.Request.
+// Returns error.
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
+	// Create the config.
+	c := createConfig(ctx, req)
+	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
+	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
+
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Create the Cognit service.
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
+	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Set physical ID
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s-mfa", c.resourceProperties.UserPoolID))
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
+
+	// create, update or delete the userpool federation.
+	err := c.run(awsCtx, req)
+	if err != nil {
+		return c.runError(ctx, req, err)
+	}
+
+	// Send the result to the pre-signed s3 url.
+	if err := req.SendWithContext(ctx, c.physicalID, nil, err); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
+// Returns error.
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
+	if err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
+			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
+		}
+	}
+	return err
+}
+
+// createConfig takes ctx and req and creates a config that contains the logger
+// and the cognito aws service.
+// Returns *config and error.
+func createConfig(ctx context.Context, req *events.Request) *config {
+	return &config{
+		log: l.Create(ctx, l.Input{
+			"service":               service,
+			"function":              function,
+			"env":                   os.Getenv("ENVIRONMENT"),
+			"stackId":               req.StackID,
+			"requestType":           req.RequestType,
+			"requestId":             req.RequestID,
+			"resourceType":          req.ResourceType,
+			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
+			"resourceProperties":    req.ResourceProperties,
+			"oldResourceProperties": req.OldResourceProperties,
+		}),
+		physicalID:            "NotAviable",
+		resourceProperties:    &MFA{},
+		oldResourceProperties: &MFA{},
+	}
+}
+
+// Creates the CognitoIdentity Service for the target account and region in req.
+// Returns error.
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
+	if err != nil {
+		return err
+	}
+
+	c.svc = clients.CognitoIdentityProvider()
+	return nil
+}
+
+// run will either set or delete the specified MFA settings on the UserPool.
+// If the domain already exists in the user pool it will be adopted into the
+// cf stack. This so that manually created domains don't have to be recreated.
+// Returns map[string]string and error.
+func (c *config) run(ctx context.Context, req *events.Request) error {
+	// Check for the correct ResourceType
+	if req.ResourceType != ResourceType {
+		return fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
+	}
+
+	switch {
+	// If Delete is run on the stack.
+	case req.RequestType == "Delete":
+		return c.deleteMFA(ctx, req)
+
+	// If Update is run on the stack.
+	case req.RequestType == "Update":
+		return c.setMFA(ctx, req, false)
+
+	// If Create is run on the stack.
+	case req.RequestType == "Create":
+		return c.setMFA(ctx, req, false)
+	}
+
+	return fmt.Errorf("Didn't get RequestType Create, Update or Delete")
+}
+
+// getMFA will get the MFA settings of the User Pool with poolID.
+// If nil is returned the User Pool doesn't exist.
+// Returns *MFA and error.
+func (c *config) getMFA(ctx context.Context, poolID string) (*MFA, error) {
+	// Just return nil, nil if the User Pool isn't specified.
+	if poolID == "" {
+		return nil, nil
+	}
+
+	r := c.svc.GetUserPoolMfaConfigRequest(
+		&cognitoidentityprovider.GetUserPoolMfaConfigInput{
+			UserPoolId: &poolID,
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
+	if err != nil {
+		// If the User Pool doesn't exists. Return nil and no error.
+		if awserrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	mfa := &MFA{
+		MfaConfiguration: string(resp.MfaConfiguration),
+		UserPoolID:       poolID,
+	}
+
+	// Only set SmsMfaConfiguration if it's not nil.
+	if resp.SmsMfaConfiguration != nil {
+		mfa.SmsMfaConfiguration = &SmsMfaConfiguration{
+			SmsAuthenticationMessage: aws.StringValue(resp.SmsMfaConfiguration.SmsAuthenticationMessage),
+		}
+		if resp.SmsMfaConfiguration.SmsConfiguration != nil {
+			mfa.SmsMfaConfiguration.SmsConfiguration = &SmsConfiguration{
+				SnsCallerArn: aws.StringValue(resp.SmsMfaConfiguration.SmsConfiguration.SnsCallerArn),
+				ExternalID:   aws.StringValue(resp.SmsMfaConfiguration.SmsConfiguration.ExternalId),
+			}
+		}
+	}
+
+	// Only set SoftwareTokenMfaConfiguration if it's not nil.
+	if resp.SoftwareTokenMfaConfiguration != nil {
+		mfa.SoftwareTokenMfaConfiguration = &SoftwareTokenMfaConfiguration{
+			Enabled: strconv.FormatBool(aws.BoolValue(resp.SoftwareTokenMfaConfiguration.Enabled)),
+		}
+	}
+
+	return mfa, nil
+}
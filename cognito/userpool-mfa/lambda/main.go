@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	userpoolmfa "github.com/dwtechnologies/custom-cf/cognito/userpool-mfa"
+)
+
+func main() {
+	lambda.Start(userpoolmfa.Handler)
+}
@@ -1,6 +1,7 @@
-package main
+package userpoolmfa
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -11,19 +12,15 @@ import (
 // setMFA will set the MFA settings specified by req.
 // If defaults is true the default MFA settings will be set.
 // error.
-func (c *config) setMFA(req *events.Request, defaults bool) error {
+func (c *config) setMFA(ctx context.Context, req *events.Request, defaults bool) error {
 	props := c.resourceProperties
 	if defaults {
 		props = &MFA{MfaConfiguration: "OFF"}
 		props.UserPoolID = c.resourceProperties.UserPoolID
 	}
 
-	switch {
-	case props.MfaConfiguration != "OFF" && props.MfaConfiguration != "ON" && props.MfaConfiguration != "OPTIONAL":
-		return fmt.Errorf("No MfaConfiguration needs to be either OFF, ON or OPTIONAL")
-
-	case props.UserPoolID == "":
-		return fmt.Errorf("No UserPoolId specified")
+	if err := props.validate(); err != nil {
+		return err
 	}
 
 	input := &cognitoidentityprovider.SetUserPoolMfaConfigInput{
@@ -56,9 +53,11 @@ func (c *config) setMFA(req *events.Request, defaults bool) error {
 		}
 	}
 
-	_, err := c.svc.SetUserPoolMfaConfigRequest(input).Send()
+	r := c.svc.SetUserPoolMfaConfigRequest(input)
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to set MFA. Error %s", err.Error())
+		return fmt.Errorf("Failed to set MFA. Error %w", err)
 	}
 
 	return nil
@@ -0,0 +1,18 @@
+package userpoolmfa
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito UserPool MFA CloudFormation Support",
+	Properties:  MFA{},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-idp:SetUserPoolMfaConfig",
+			},
+			Resources: []string{"arn:aws:cognito-idp:*:${AWS::AccountId}:userpool/*"},
+		},
+	},
+}
@@ -0,0 +1,32 @@
+package userpoolmfa
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &MFA{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the MFA settings.
+// Returns error.
+func (m *MFA) validate() error {
+	switch {
+	case m.MfaConfiguration != "OFF" && m.MfaConfiguration != "ON" && m.MfaConfiguration != "OPTIONAL":
+		return spec.Errorf("MfaConfiguration", "No MfaConfiguration needs to be either OFF, ON or OPTIONAL")
+
+	case m.UserPoolID == "":
+		return spec.Errorf("UserPoolId", "No UserPoolId specified")
+	}
+	return nil
+}
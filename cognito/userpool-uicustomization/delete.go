@@ -1,6 +1,7 @@
-package main
+package userpooluicustomization
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,15 +13,17 @@ const defaultCSS = ".logo-customizable {max-width: 60%;max-height: 30%;}.banner-
 
 // deleteUICustomization will resetset UI details for clientID on the user pool
 // Returns error.
-func (c *config) deleteUICustomization(req *events.Request) error {
-	_, err := c.svc.SetUICustomizationRequest(
+func (c *config) deleteUICustomization(ctx context.Context, req *events.Request) error {
+	r := c.svc.SetUICustomizationRequest(
 		&cognitoidentityprovider.SetUICustomizationInput{
 			CSS:        aws.String(defaultCSS),
 			ClientId:   &c.resourceProperties.ClientID,
 			UserPoolId: &c.resourceProperties.UserPoolID,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to delete UI Customization. Error %s", err.Error())
+		return fmt.Errorf("Failed to delete UI Customization. Error %w", err)
 	}
 
 	return nil
@@ -1,13 +1,15 @@
-package main
+// Package userpooluicustomization handles the Custom::CognitoUserPoolUICustomization resource.
+package userpooluicustomization
 
 import (
 	"context"
 	"fmt"
 	"os"
 
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 	l "github.com/nuttmeister/llogger"
 )
@@ -16,7 +18,7 @@ import (
 const (
 	service      = "custom-cf"
 	function     = "uicustomization"
-	resourceType = "Custom::CognitoUserPoolUICustomization"
+	ResourceType = "Custom::CognitoUserPoolUICustomization"
 	httpTimeout  = 30
 )
 
@@ -30,45 +32,59 @@ type config struct {
 }
 
 type UICustomization struct {
-	CSS        string `json:"CSS"`
-	ClientID   string `json:"ClientId"`
-	ImageFile  []byte `json:"ImageFile"`
-	UserPoolID string `json:"UserPoolId"`
+	CSS        string `json:"CSS" doc:"CSS to use for the UI"`
+	ClientID   string `json:"ClientId" cfn:"required,createOnly" doc:"The UserPool Client ID"`
+	ImageFile  []byte `json:"ImageFile" doc:"Base64 encoded Image"`
+	UserPoolID string `json:"UserPoolId" cfn:"required,createOnly" doc:"The ID of the UserPool the Client belongs to"`
 }
 
-func main() {
-	lambda.Start(handler)
-}
-
-// handler takes context.Context and *events.Request.
+// Handler takes context.Context and *events.Request.
 // Returns error.
-func handler(ctx context.Context, req *events.Request) error {
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
 	// Create the config.
 	c := createConfig(ctx, req)
 	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
 	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
 
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
 	// Create the Cognit service.
-	if err := c.createCognitoService(); err != nil {
-		return c.runError(req, err)
+	if err := c.createCognitoService(ctx, req); err != nil {
+		return c.runError(ctx, req, err)
 	}
 
 	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
 	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Set physical ID
-	c.physicalID = fmt.Sprintf("%s-%s", c.resourceProperties.UserPoolID, c.resourceProperties.ClientID)
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s-%s", c.resourceProperties.UserPoolID, c.resourceProperties.ClientID))
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
 
 	// create, update or delete the userpool federation.
-	data, err := c.run(req)
+	data, err := c.run(awsCtx, req)
 	if err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Send the result to the pre-signed s3 url.
-	if err := req.Send(c.physicalID, data, err); err != nil {
+	if err := req.SendWithContext(ctx, c.physicalID, data, err); err != nil {
 		return err
 	}
 	return nil
@@ -88,6 +104,7 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 			"requestId":             req.RequestID,
 			"resourceType":          req.ResourceType,
 			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
 			"resourceProperties":    req.ResourceProperties,
 			"oldResourceProperties": req.OldResourceProperties,
 		}),
@@ -98,46 +115,47 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 }
 
 // runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
 // Returns error.
-func (c *config) runError(req *events.Request, err error) error {
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
 	if err != nil {
 		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
-		if err := req.Send(c.physicalID, nil, err); err != nil {
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
 			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
 		}
 	}
 	return err
 }
 
-// Creates AWS Config and the CognitoIdentity Service.
+// Creates the CognitoIdentity Service for the target account and region in req.
 // Returns error.
-func (c *config) createCognitoService() error {
-	cfg, err := external.LoadDefaultAWSConfig()
+func (c *config) createCognitoService(ctx context.Context, req *events.Request) error {
+	clients, err := awsclient.New(ctx, req, ResourceType)
 	if err != nil {
-		return fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+		return err
 	}
 
-	c.svc = cognitoidentityprovider.New(cfg)
+	c.svc = clients.CognitoIdentityProvider()
 	return nil
 }
 
 // run will either create, update or delete the UI customization
 // Returns map[string]string and error.
-func (c *config) run(req *events.Request) (map[string]string, error) {
+func (c *config) run(ctx context.Context, req *events.Request) (map[string]string, error) {
 	// Check for the correct ResourceType
-	if req.ResourceType != resourceType {
-		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", resourceType, req.ResourceType)
+	if req.ResourceType != ResourceType {
+		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
 	}
 
 	switch {
 	case req.RequestType == "Delete":
-		return nil, c.deleteUICustomization(req)
+		return nil, c.deleteUICustomization(ctx, req)
 
 	case req.RequestType == "Create":
-		return c.setUICustomization(req)
+		return c.setUICustomization(ctx, req)
 
 	case req.RequestType == "Update":
-		return c.setUICustomization(req)
+		return c.setUICustomization(ctx, req)
 	}
 
 	return nil, fmt.Errorf("Didn't get RequestType Create, Update or Delete")
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	userpooluicustomization "github.com/dwtechnologies/custom-cf/cognito/userpool-uicustomization"
+)
+
+func main() {
+	lambda.Start(userpooluicustomization.Handler)
+}
@@ -1,6 +1,7 @@
-package main
+package userpooluicustomization
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -10,16 +11,22 @@ import (
 // setUICustomization will set UI details for clientID on the user pool with
 // settings specified by req.
 // Returns a map of properties and error.
-func (c *config) setUICustomization(req *events.Request) (map[string]string, error) {
-	resp, err := c.svc.SetUICustomizationRequest(
+func (c *config) setUICustomization(ctx context.Context, req *events.Request) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
+
+	r := c.svc.SetUICustomizationRequest(
 		&cognitoidentityprovider.SetUICustomizationInput{
 			CSS:        &c.resourceProperties.CSS,
 			ClientId:   &c.resourceProperties.ClientID,
 			ImageFile:  c.resourceProperties.ImageFile,
 			UserPoolId: &c.resourceProperties.UserPoolID,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	resp, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to set UI Customization. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to set UI Customization. Error %w", err)
 	}
 
 	return map[string]string{
@@ -0,0 +1,23 @@
+package userpooluicustomization
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "Cognito UserPool UI Customization CloudFormation Support",
+	Properties:  UICustomization{},
+	Attributes: []spec.Attribute{
+		{Name: "CSSVersion", Description: "The version of the CSS"},
+		{Name: "ClientId", Description: "The ID of the Client"},
+		{Name: "UserPoolId", Description: "The ID of the UserPool"},
+	},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"cognito-idp:SetUICustomization",
+			},
+			Resources: []string{"arn:aws:cognito-idp:*:${AWS::AccountId}:userpool/*"},
+		},
+	},
+}
@@ -0,0 +1,32 @@
+package userpooluicustomization
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &UICustomization{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the UI customization.
+// Returns error.
+func (ui *UICustomization) validate() error {
+	switch {
+	case ui.ClientID == "":
+		return spec.Errorf("ClientId", "No ClientId specified")
+
+	case ui.UserPoolID == "":
+		return spec.Errorf("UserPoolId", "No UserPoolId specified")
+	}
+	return nil
+}
@@ -1,4 +1,5 @@
-package main
+// Package example is boilerplate for creating a new custom resource.
+package example
 
 import (
 	"context"
@@ -6,18 +7,18 @@ import (
 	"os"
 
 	// External
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
 	"github.com/dwtechnologies/custom-cf/lib/events"
 	l "github.com/nuttmeister/llogger"
-
-	// External - AWS
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // http client timeout in seconds.
 const (
 	service      = "custom-cf"
 	function     = "myresource"         // Replace with the function name
-	resourceType = "Custom::MyResource" // Change to the Resource Name you want to use.
+	ResourceType = "Custom::MyResource" // Change to the Resource Name you want to use.
 	httpTimeout  = 30
 )
 
@@ -37,25 +38,37 @@ type ResourceProperties struct {
 	MyResourceField2 string `json:"MyResourceField2"`
 }
 
-func main() {
-	lambda.Start(handler)
-}
-
-// handler takes context.Context and *events.Request.
+// Handler takes context.Context and *events.Request.
 // Returns error.
-func handler(ctx context.Context, req *events.Request) error {
+func Handler(ctx context.Context, req *events.Request) error {
+	// Remember the invocation, so that FAILED responses point to its logs.
+	req.Lambda = events.LambdaFromContext(ctx)
+
 	// Create the config.
 	c := createConfig(ctx, req)
 	c.log.Print(l.Input{"loglevel": "info", "message": "Function started"})
 	defer c.log.Print(l.Input{"loglevel": "info", "message": "Function finished"})
 
+	// Never answer a request that wants the response sent anywhere else than to S3.
+	if err := req.CheckResponseURL(); err != nil {
+		c.log.Print(l.Input{"loglevel": "error", "security": true, "responseUrl": req.ResponseURL, "message": fmt.Sprintf("Rejected request, no response will be sent. Error %s", err.Error())})
+		return err
+	}
+
+	// Validate the request and normalise its RequestType.
+	if err := req.Validate(); err != nil {
+		return c.runError(ctx, req, err)
+	}
+
 	// Function to create the AWS service (if needed) and set it to c.svc.
-	// If creation of the service fails it should return c.runError(req, err).
+	// Create the clients with awsclient.New(ctx, req, ResourceType) so that the common RoleArn,
+	// ExternalId and Region properties are supported and throttled requests are retried.
+	// If creation of the service fails it should return c.runError(ctx, req, err).
 
 	// Unmarshal the ResourceProperties and OldResourceProperties into the config.
 	// If it fails send FAILED status back to CF and return error.
 	if err := req.Unmarshal(c.resourceProperties, c.oldResourceProperties); err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Set physical ID for the resource. Please note that if the physical ID differs
@@ -63,27 +76,34 @@ func handler(ctx context.Context, req *events.Request) error {
 	// will be run on the previous physical id.
 	// This is how you control when a resource needs replacement instead of just pure
 	// updating it.
-	c.physicalID = fmt.Sprintf("%s-%s", c.resourceProperties.MyResourceField1, c.resourceProperties.MyResourceField2)
+	// awsconfig.PhysicalID adds the target account and region (if set) so that the resource
+	// is replaced if it's moved to another account or region.
+	c.physicalID = awsconfig.PhysicalID(req, fmt.Sprintf("%s-%s", c.resourceProperties.MyResourceField1, c.resourceProperties.MyResourceField2))
+
+	// AWS requests are aborted in time to still respond to CloudFormation.
+	awsCtx, cancel := awsclient.Context(ctx)
+	defer cancel()
 
 	// create, update or delete the resource.
-	data, err := c.run(req)
+	data, err := c.run(awsCtx, req)
 	if err != nil {
-		return c.runError(req, err)
+		return c.runError(ctx, req, err)
 	}
 
 	// Send the result to the pre-signed s3 url.
-	if err := req.Send(c.physicalID, data, err); err != nil {
+	if err := req.SendWithContext(ctx, c.physicalID, data, err); err != nil {
 		return err
 	}
 	return nil
 }
 
 // runError takes error and logs it and sends a failure request to the s3 pre-signed url.
+// AWS errors get a friendly Reason in front of them, see lib/awserrors.
 // Returns error.
-func (c *config) runError(req *events.Request, err error) error {
+func (c *config) runError(ctx context.Context, req *events.Request, err error) error {
 	if err != nil {
 		c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
-		if err := req.Send(c.physicalID, nil, err); err != nil {
+		if err := req.SendWithContext(ctx, c.physicalID, nil, awserrors.Reason(err)); err != nil {
 			c.log.Print(l.Input{"loglevel": "error", "message": err.Error()})
 		}
 	}
@@ -104,6 +124,7 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 			"requestId":             req.RequestID,
 			"resourceType":          req.ResourceType,
 			"logicalResourceId":     req.LogicalResourceID,
+			"lambda":                req.Lambda,
 			"resourceProperties":    req.ResourceProperties,
 			"oldResourceProperties": req.OldResourceProperties,
 		}),
@@ -118,10 +139,10 @@ func createConfig(ctx context.Context, req *events.Request) *config {
 // be obtained by CloudFormation Fn::GetAtt function, so that other
 // resources can reference data from this resource.
 // Returns map[string]string and error.
-func (c *config) run(req *events.Request) (map[string]string, error) {
+func (c *config) run(ctx context.Context, req *events.Request) (map[string]string, error) {
 	// Check for the correct ResourceType.
-	if req.ResourceType != resourceType {
-		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", resourceType, req.ResourceType)
+	if req.ResourceType != ResourceType {
+		return nil, fmt.Errorf("Wrong ResourceType in request. Expected %s but got %s", ResourceType, req.ResourceType)
 	}
 
 	// Add logic for checking if the resource with the same data already exists.
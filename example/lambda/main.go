@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	example "github.com/dwtechnologies/custom-cf/example"
+)
+
+func main() {
+	lambda.Start(example.Handler)
+}
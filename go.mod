@@ -1,7 +1,10 @@
 module github.com/dwtechnologies/custom-cf
 
+go 1.16
+
 require (
 	github.com/aws/aws-lambda-go v1.9.0
 	github.com/aws/aws-sdk-go-v2 v0.7.0
 	github.com/nuttmeister/llogger v0.0.0-20181220074125-69469dbe62c0
+	gopkg.in/yaml.v3 v3.0.1
 )
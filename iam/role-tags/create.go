@@ -1,8 +1,8 @@
-package main
+package roletags
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
@@ -12,7 +12,11 @@ import (
 // createTags will set tags for RoleName with
 // settings specified by req.
 // Returns a map of properties and error.
-func (c *config) createTags(req *events.Request) (map[string]string, error) {
+func (c *config) createTags(ctx context.Context, req *events.Request) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
+
 	// append CF stack-id
 	c.resourceProperties.Tags = append(c.resourceProperties.Tags, iam.Tag{
 		Key:   aws.String("cloudformation:stack-id"),
@@ -20,19 +24,20 @@ func (c *config) createTags(req *events.Request) (map[string]string, error) {
 	})
 
 	// append CF stack-name
-	stackName := strings.Split(req.StackID, "/")[1]
 	c.resourceProperties.Tags = append(c.resourceProperties.Tags, iam.Tag{
 		Key:   aws.String("cloudformation:stack-name"),
-		Value: aws.String(stackName),
+		Value: aws.String(req.Stack.Name),
 	})
 
-	_, err := c.svc.TagRoleRequest(
+	r := c.svc.TagRoleRequest(
 		&iam.TagRoleInput{
 			RoleName: &c.resourceProperties.RoleName,
 			Tags:     c.resourceProperties.Tags,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to tag role. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to tag role. Error %w", err)
 	}
 
 	return map[string]string{}, nil
@@ -1,6 +1,7 @@
-package main
+package roletags
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/iam"
@@ -10,7 +11,7 @@ import (
 // deleteTags will delete all tags for RoleName with
 // settings specified by req.
 // Returns a map of properties and error.
-func (c *config) deleteTags(req *events.Request) error {
+func (c *config) deleteTags(ctx context.Context, req *events.Request) error {
 	// get current tags
 	curTagKeys := []string{}
 	for _, tag := range c.resourceProperties.Tags {
@@ -21,13 +22,15 @@ func (c *config) deleteTags(req *events.Request) error {
 	curTagKeys = append(curTagKeys, "cloudformation:stack-id")
 	curTagKeys = append(curTagKeys, "cloudformation:stack-name")
 
-	_, err := c.svc.UntagRoleRequest(
+	r := c.svc.UntagRoleRequest(
 		&iam.UntagRoleInput{
 			RoleName: &c.resourceProperties.RoleName,
 			TagKeys:  curTagKeys,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return fmt.Errorf("Failed to tag role. Error %s", err.Error())
+		return fmt.Errorf("Failed to tag role. Error %w", err)
 	}
 
 	return nil
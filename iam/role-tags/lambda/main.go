@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	roletags "github.com/dwtechnologies/custom-cf/iam/role-tags"
+)
+
+func main() {
+	lambda.Start(roletags.Handler)
+}
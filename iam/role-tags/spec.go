@@ -0,0 +1,23 @@
+package roletags
+
+import "github.com/dwtechnologies/custom-cf/lib/spec"
+
+// Spec describes the resource for the generated template, README and schema.
+var Spec = &spec.Resource{
+	Type:        ResourceType,
+	Description: "IAM Role Tags CloudFormation Support",
+	Properties:  RoleTags{},
+	Policy: []spec.Statement{
+		{
+			Actions: []string{
+				"iam:TagRole",
+				"iam:UntagRole",
+			},
+			Resources: []string{"arn:aws:iam::${AWS::AccountId}:role/*"},
+		},
+	},
+	Docs: map[string]string{
+		"Tag.Key":   "Name of tag",
+		"Tag.Value": "Value of tag",
+	},
+}
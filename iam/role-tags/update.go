@@ -1,8 +1,8 @@
-package main
+package roletags
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
@@ -12,7 +12,11 @@ import (
 // updateTags will set tags for RoleName with
 // settings specified by req.
 // Returns a map of properties and error.
-func (c *config) updateTags(req *events.Request) (map[string]string, error) {
+func (c *config) updateTags(ctx context.Context, req *events.Request) (map[string]string, error) {
+	if err := c.resourceProperties.validate(); err != nil {
+		return nil, err
+	}
+
 	// get current tags
 	curTagKeys := []string{}
 	for _, tag := range c.oldResourceProperties.Tags {
@@ -28,13 +32,15 @@ func (c *config) updateTags(req *events.Request) (map[string]string, error) {
 	// remove tags not current
 	unTags := slicesDiff(curTagKeys, newTagKeys)
 	if len(unTags) > 0 {
-		_, err := c.svc.UntagRoleRequest(
+		r := c.svc.UntagRoleRequest(
 			&iam.UntagRoleInput{
 				RoleName: &c.resourceProperties.RoleName,
 				TagKeys:  unTags,
-			}).Send()
+			})
+		r.SetContext(ctx)
+		_, err := r.Send()
 		if err != nil {
-			return nil, fmt.Errorf("Failed to tag resource. Error %s", err.Error())
+			return nil, fmt.Errorf("Failed to tag resource. Error %w", err)
 		}
 	}
 
@@ -45,20 +51,21 @@ func (c *config) updateTags(req *events.Request) (map[string]string, error) {
 	})
 
 	// append CF stack-name
-	stackName := strings.Split(req.StackID, "/")[1]
 	c.resourceProperties.Tags = append(c.resourceProperties.Tags, iam.Tag{
 		Key:   aws.String("cloudformation:stack-name"),
-		Value: aws.String(stackName),
+		Value: aws.String(req.Stack.Name),
 	})
 
 	// add c.resourceProperties.Tags tags
-	_, err := c.svc.TagRoleRequest(
+	r := c.svc.TagRoleRequest(
 		&iam.TagRoleInput{
 			RoleName: &c.resourceProperties.RoleName,
 			Tags:     c.resourceProperties.Tags,
-		}).Send()
+		})
+	r.SetContext(ctx)
+	_, err := r.Send()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to tag role. Error %s", err.Error())
+		return nil, fmt.Errorf("Failed to tag role. Error %w", err)
 	}
 
 	return map[string]string{}, nil
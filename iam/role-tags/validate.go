@@ -0,0 +1,34 @@
+package roletags
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Validate takes properties and validates them in the same way as the lambda
+// function does before calling AWS. Is used to lint templates.
+// Returns error.
+func Validate(properties json.RawMessage) error {
+	props := &RoleTags{}
+	if err := json.Unmarshal(properties, props); err != nil {
+		return fmt.Errorf("Couldn't unmarshal properties. Error %s", err.Error())
+	}
+	return props.validate()
+}
+
+// validate validates the role name and tags.
+// Returns error.
+func (r *RoleTags) validate() error {
+	if r.RoleName == "" {
+		return spec.Errorf("RoleName", "No RoleName specified")
+	}
+
+	for i, tag := range r.Tags {
+		if tag.Key == nil || *tag.Key == "" {
+			return spec.Errorf(fmt.Sprintf("Tags.%d.Key", i), "No Key set in Tags")
+		}
+	}
+	return nil
+}
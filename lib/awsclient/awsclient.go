@@ -0,0 +1,184 @@
+// Package awsclient creates the AWS service clients used by the custom resources.
+// All clients share a retryer that backs off on throttling (Cognito allows very few
+// requests per second for most of its APIs) and that stops retrying in time for the
+// lambda function to still be able to respond to CloudFormation.
+package awsclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentity"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/awserrors"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+const (
+	userAgent = "custom-cf"
+
+	maxRetries = 10               // Retries per request, the deadline will often stop retries before this.
+	maxDelay   = 20 * time.Second // The longest we will wait between two retries.
+
+	// responseTime is the time reserved at the end of the lambda functions
+	// execution for sending the response to CloudFormation.
+	responseTime = 10 * time.Second
+)
+
+// Factory creates AWS service clients that share the same config.
+type Factory struct {
+	cfg aws.Config
+}
+
+// New takes ctx, req and resourceType and loads the AWS config for the target account
+// and region of req. The retries of all requests will end before the deadline of ctx.
+// Returns *Factory and error.
+func New(ctx context.Context, req *events.Request, resourceType string) (*Factory, error) {
+	cfg, err := awsconfig.Load(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromConfig(ctx, cfg, resourceType), nil
+}
+
+// NewDefault takes ctx and resourceType and loads the AWS config of the lambda function itself,
+// for requests that are never made in the Target account, such as to CloudFormation.
+// Returns *Factory and error.
+func NewDefault(ctx context.Context, resourceType string) (*Factory, error) {
+	cfg, err := awsconfig.LoadDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	return FromConfig(ctx, cfg, resourceType), nil
+}
+
+// FromConfig takes ctx, cfg and resourceType and returns a Factory that creates
+// clients from a copy of cfg.
+// Returns *Factory.
+func FromConfig(ctx context.Context, cfg aws.Config, resourceType string) *Factory {
+	cfg = cfg.Copy()
+
+	deadline, ok := ctx.Deadline()
+	if ok {
+		deadline = deadline.Add(-responseTime)
+	}
+
+	cfg.Retryer = Retryer{
+		DefaultRetryer: aws.DefaultRetryer{NumMaxRetries: maxRetries},
+		Deadline:       deadline,
+	}
+	// Always ask the retryer, even if the SDK already decided if the request is retryable.
+	cfg.EnforceShouldRetryCheck = true
+
+	// Identify the requests made by custom-cf and the resource in CloudTrail.
+	cfg.Handlers.Build.PushBack(aws.MakeAddToUserAgentFreeFormHandler(fmt.Sprintf("%s/%s", userAgent, resourceType)))
+
+	return &Factory{cfg: cfg}
+}
+
+// Context takes ctx and returns a copy of it that is done when there is only the time to
+// respond to CloudFormation left before the deadline of ctx. Set it on every request with
+// SetContext, so requests that are in flight are aborted in time to respond with a proper failure.
+// Returns context.Context and context.CancelFunc.
+func Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-responseTime))
+}
+
+// Config returns a copy of the config that the clients are created from.
+// Returns aws.Config.
+func (f *Factory) Config() aws.Config {
+	return f.cfg.Copy()
+}
+
+// CloudFormation returns a new CloudFormation client.
+// Returns *cloudformation.CloudFormation.
+func (f *Factory) CloudFormation() *cloudformation.CloudFormation {
+	return cloudformation.New(f.cfg)
+}
+
+// CognitoIdentityProvider returns a new Cognito User Pools client.
+// Returns *cognitoidentityprovider.CognitoIdentityProvider.
+func (f *Factory) CognitoIdentityProvider() *cognitoidentityprovider.CognitoIdentityProvider {
+	return cognitoidentityprovider.New(f.cfg)
+}
+
+// CognitoIdentity returns a new Cognito Identity Pools client.
+// Returns *cognitoidentity.CognitoIdentity.
+func (f *Factory) CognitoIdentity() *cognitoidentity.CognitoIdentity {
+	return cognitoidentity.New(f.cfg)
+}
+
+// IAM returns a new IAM client.
+// Returns *iam.IAM.
+func (f *Factory) IAM() *iam.IAM {
+	return iam.New(f.cfg)
+}
+
+// Route53 returns a new Route 53 client.
+// Returns *route53.Route53.
+func (f *Factory) Route53() *route53.Route53 {
+	return route53.New(f.cfg)
+}
+
+// SecretsManager returns a new Secrets Manager client.
+// Returns *secretsmanager.SecretsManager.
+func (f *Factory) SecretsManager() *secretsmanager.SecretsManager {
+	return secretsmanager.New(f.cfg)
+}
+
+// Retryer retries throttled and failed requests with exponential backoff
+// like aws.DefaultRetryer, but never waits past Deadline. A zero Deadline
+// means that there is no deadline.
+type Retryer struct {
+	aws.DefaultRetryer
+	Deadline time.Time
+}
+
+// ShouldRetry returns true if r should be retried and there is time left before the deadline.
+// ConcurrentModificationException is retried as well, since it only means that someone else
+// changed the resource at the same time.
+// Returns bool.
+func (rt Retryer) ShouldRetry(r *aws.Request) bool {
+	if !rt.Deadline.IsZero() && !time.Now().Before(rt.Deadline) {
+		return false
+	}
+
+	if awserrors.IsConcurrentModification(r.Error) {
+		return true
+	}
+	return rt.DefaultRetryer.ShouldRetry(r)
+}
+
+// RetryRules returns the delay before r is retried. The delay is never longer
+// than maxDelay and never reaches past the deadline.
+// Returns time.Duration.
+func (rt Retryer) RetryRules(r *aws.Request) time.Duration {
+	delay := rt.DefaultRetryer.RetryRules(r)
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if !rt.Deadline.IsZero() {
+		if left := time.Until(rt.Deadline); delay > left {
+			delay = left
+		}
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,109 @@
+package awsclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+// Test that retries are throttle-aware and bounded by the deadline.
+func TestRetryer(t *testing.T) {
+	throttled := func() *aws.Request {
+		return &aws.Request{
+			HTTPResponse: &http.Response{StatusCode: 400},
+			Error:        awserr.New("TooManyRequestsException", "Too many requests", nil),
+			RetryCount:   8,
+		}
+	}
+
+	rt := Retryer{DefaultRetryer: aws.DefaultRetryer{NumMaxRetries: maxRetries}}
+	if !rt.ShouldRetry(throttled()) {
+		t.Errorf("Expected throttled request to be retried")
+	}
+	if delay := rt.RetryRules(throttled()); delay > maxDelay || delay < 500*time.Millisecond {
+		t.Errorf("Expected delay between 500ms and %s but got %s", maxDelay, delay)
+	}
+
+	concurrent := &aws.Request{
+		HTTPResponse: &http.Response{StatusCode: 400},
+		Error:        awserr.New("ConcurrentModificationException", "Modified", nil),
+	}
+	if !rt.ShouldRetry(concurrent) {
+		t.Errorf("Expected ConcurrentModificationException to be retried")
+	}
+
+	invalid := &aws.Request{
+		HTTPResponse: &http.Response{StatusCode: 400},
+		Error:        awserr.New("InvalidParameterException", "Invalid", nil),
+	}
+	if rt.ShouldRetry(invalid) {
+		t.Errorf("Expected InvalidParameterException not to be retried")
+	}
+
+	// Delay never reaches past the deadline.
+	rt.Deadline = time.Now().Add(time.Second)
+	if delay := rt.RetryRules(throttled()); delay > time.Second {
+		t.Errorf("Expected delay to be at most 1s but got %s", delay)
+	}
+
+	// No retries after the deadline.
+	rt.Deadline = time.Now().Add(-time.Second)
+	if rt.ShouldRetry(throttled()) {
+		t.Errorf("Expected no retries after the deadline")
+	}
+}
+
+// Test that the deadline is taken from ctx with time reserved for the response.
+func TestFromConfig(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	f := FromConfig(ctx, aws.Config{Region: "eu-west-1"}, "Custom::Test")
+	rt, ok := f.Config().Retryer.(Retryer)
+	if !ok {
+		t.Fatalf("Expected Retryer but got %T", f.Config().Retryer)
+	}
+	if !rt.Deadline.Equal(deadline.Add(-responseTime)) {
+		t.Errorf("Expected deadline %s but got %s", deadline.Add(-responseTime), rt.Deadline)
+	}
+
+	// Without a deadline in ctx there is no deadline.
+	f = FromConfig(context.Background(), aws.Config{Region: "eu-west-1"}, "Custom::Test")
+	if !f.Config().Retryer.(Retryer).Deadline.IsZero() {
+		t.Errorf("Expected no deadline")
+	}
+
+	// The user agent identifies custom-cf and the resource type.
+	r := &aws.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	f.cfg.Handlers.Build.Run(r)
+	if ua := r.HTTPRequest.Header.Get("User-Agent"); ua != "custom-cf/Custom::Test" {
+		t.Errorf("Expected user agent custom-cf/Custom::Test but got %s", ua)
+	}
+}
+
+// Test that the context of requests ends in time to respond to CloudFormation.
+func TestContext(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	ctx, cancel := Context(parent)
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(deadline.Add(-responseTime)) {
+		t.Errorf("Expected deadline %s but got %s", deadline.Add(-responseTime), d)
+	}
+
+	ctx, cancel = Context(context.Background())
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("Expected no deadline without a deadline on the parent")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Errorf("Expected the context to be cancelled")
+	}
+}
@@ -0,0 +1,94 @@
+package awsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+// WithParams takes r and params and adds the fields of params to the JSON body of r, next to
+// the input of r. It's for parameters that AWS supports but that the AWS SDK in use doesn't
+// know yet. params must marshal to a JSON object, fields that are already in the input are
+// replaced. Only works for services with a JSON protocol, such as Cognito.
+func WithParams(r *aws.Request, params interface{}) {
+	r.Handlers.Build.PushBack(func(r *aws.Request) {
+		if r.Error != nil {
+			return
+		}
+
+		if err := mergeParams(r, params); err != nil {
+			r.Error = awserr.New("SerializationError", "failed adding params to the request", err)
+		}
+	})
+}
+
+// WithResult takes r and v and unmarshals the JSON response of r into v as well as into the
+// output of r. It's for response fields that AWS returns but that the AWS SDK in use doesn't
+// know yet. v is only set if the request succeeds.
+func WithResult(r *aws.Request, v interface{}) {
+	r.Handlers.Unmarshal.PushFront(func(r *aws.Request) {
+		b, err := ioutil.ReadAll(r.HTTPResponse.Body)
+		r.HTTPResponse.Body.Close()
+		r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+		if err == nil && len(b) > 0 {
+			err = json.Unmarshal(b, v)
+		}
+		if err != nil {
+			r.Error = awserr.New("SerializationError", "failed decoding the result of the response", err)
+		}
+	})
+}
+
+// mergeParams takes r and params and adds params to the built JSON body of r.
+// Returns error.
+func mergeParams(r *aws.Request, params interface{}) error {
+	extra, err := toObject(params)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]json.RawMessage{}
+	if r.Body != nil {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &body); err != nil {
+				return fmt.Errorf("The body of the request isn't a JSON object. Error %s", err.Error())
+			}
+		}
+	}
+
+	for key, val := range extra {
+		body[key] = val
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	r.SetBufferBody(b)
+	return nil
+}
+
+// toObject takes v and returns it as the fields of a JSON object.
+// Returns map[string]json.RawMessage and error.
+func toObject(v interface{}) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("Params need to be a JSON object. Error %s", err.Error())
+	}
+	return obj, nil
+}
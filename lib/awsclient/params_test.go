@@ -0,0 +1,76 @@
+package awsclient
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/defaults"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+)
+
+// Test that params are added to the request and that the result is read from the response.
+func TestWithParams(t *testing.T) {
+	body := map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("Expected a JSON body but got %s", b)
+		}
+		w.Write([]byte(`{"UserPoolClient":{"ClientId":"abc","AccessTokenValidity":5}}`))
+	}))
+	defer srv.Close()
+
+	cfg := defaults.Config()
+	cfg.Region = "eu-west-1"
+	cfg.Credentials = aws.NewStaticCredentialsProvider("key", "secret", "")
+	cfg.EndpointResolver = aws.ResolveWithEndpointURL(srv.URL)
+	svc := FromConfig(context.Background(), cfg, "Custom::Test").CognitoIdentityProvider()
+
+	result := &struct {
+		UserPoolClient struct {
+			AccessTokenValidity int64
+		}
+	}{}
+
+	r := svc.UpdateUserPoolClientRequest(&cognitoidentityprovider.UpdateUserPoolClientInput{
+		ClientId:   aws.String("abc"),
+		UserPoolId: aws.String("eu-west-1_abc"),
+		ClientName: aws.String("client"),
+	})
+	WithParams(r.Request, map[string]interface{}{"AccessTokenValidity": 5, "ClientName": "renamed"})
+	WithResult(r.Request, result)
+
+	resp, err := r.Send()
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	switch {
+	case body["ClientId"] != "abc" || body["UserPoolId"] != "eu-west-1_abc":
+		t.Errorf("Expected the input to be kept but got %v", body)
+
+	case body["AccessTokenValidity"] != float64(5) || body["ClientName"] != "renamed":
+		t.Errorf("Expected the params to be added but got %v", body)
+
+	case aws.StringValue(resp.UserPoolClient.ClientId) != "abc":
+		t.Errorf("Expected the output to be unmarshaled but got %v", resp.UserPoolClient)
+
+	case result.UserPoolClient.AccessTokenValidity != 5:
+		t.Errorf("Expected the result to be unmarshaled but got %v", result)
+	}
+
+	// Params that aren't a JSON object fail the request before it's sent.
+	r = svc.UpdateUserPoolClientRequest(&cognitoidentityprovider.UpdateUserPoolClientInput{
+		ClientId:   aws.String("abc"),
+		UserPoolId: aws.String("eu-west-1_abc"),
+	})
+	WithParams(r.Request, []string{"AccessTokenValidity"})
+	if _, err := r.Send(); err == nil {
+		t.Errorf("Expected error for params that aren't an object")
+	}
+}
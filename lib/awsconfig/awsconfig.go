@@ -0,0 +1,143 @@
+// Package awsconfig loads the AWS config that the custom resources use to create
+// their service clients. Every custom resource supports the common properties
+// RoleArn, ExternalId and Region, which makes it possible to manage resources in
+// another account (by assuming a role) or in another region than the lambda
+// function is running in.
+package awsconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/aws/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// roleSessionName is the session name used when assuming RoleArn,
+// so that the calls can be found in CloudTrail of the target account.
+const roleSessionName = "custom-cf"
+
+var regionRegexp = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d$`)
+
+// Target contains the common properties that decide which account and region
+// a custom resource manages its resource in.
+type Target struct {
+	RoleArn    string `json:"RoleArn,omitempty" cfn:"createOnly" doc:"ARN of a role to assume before calling AWS. The role needs to trust the lambda functions role"`
+	ExternalID string `json:"ExternalId,omitempty" cfn:"createOnly" doc:"External ID to use when assuming RoleArn"`
+	Region     string `json:"Region,omitempty" cfn:"createOnly" doc:"Region to manage the resource in. Defaults to the region of the lambda function"`
+}
+
+// Load takes req and loads the default AWS config and points it at the Target
+// in the requests ResourceProperties.
+// Returns aws.Config and error.
+func Load(req *events.Request) (aws.Config, error) {
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+	}
+
+	return Apply(cfg, req.ResourceProperties)
+}
+
+// LoadDefault loads the default AWS config of the lambda function itself, without any Target.
+// Use it for calls about the stack, such as to CloudFormation, which are never made in the Target account.
+// Returns aws.Config and error.
+func LoadDefault() (aws.Config, error) {
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("Couldn't create AWS cfg. Error: %s", err.Error())
+	}
+
+	return Apply(cfg, nil)
+}
+
+// Apply takes cfg and properties and returns a copy of cfg that is pointed at the
+// Target in properties. If RoleArn is set the role will be assumed with the
+// credentials of cfg. The services in Endpoints are pointed at their URL.
+// Returns aws.Config and error.
+func Apply(cfg aws.Config, properties json.RawMessage) (aws.Config, error) {
+	t, err := ParseTarget(properties)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	cfg = cfg.Copy()
+
+	// Local emulators replace AWS before any request is made, including the one to assume RoleArn.
+	if err := applyEndpoints(&cfg); err != nil {
+		return aws.Config{}, err
+	}
+
+	if t.RoleArn != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.New(cfg), t.RoleArn)
+		provider.RoleSessionName = roleSessionName
+		if t.ExternalID != "" {
+			provider.ExternalID = aws.String(t.ExternalID)
+		}
+		cfg.Credentials = provider
+	}
+
+	if t.Region != "" {
+		cfg.Region = t.Region
+	}
+
+	return cfg, nil
+}
+
+// ParseTarget takes properties and returns the validated Target in them.
+// Returns *Target and error.
+func ParseTarget(properties json.RawMessage) (*Target, error) {
+	t := &Target{}
+	if len(properties) == 0 {
+		return t, nil
+	}
+
+	if err := json.Unmarshal(properties, t); err != nil {
+		return nil, fmt.Errorf("Couldn't unmarshal RoleArn, ExternalId and Region. Error %s", err.Error())
+	}
+
+	switch {
+	case t.ExternalID != "" && t.RoleArn == "":
+		return nil, fmt.Errorf("ExternalId can only be used together with RoleArn")
+
+	case t.Region != "" && !regionRegexp.MatchString(t.Region):
+		return nil, fmt.Errorf("Region %s is not a valid region", t.Region)
+	}
+
+	if t.RoleArn != "" {
+		a, err := arn.Parse(t.RoleArn)
+		if err != nil || a.Service != "iam" || !strings.HasPrefix(a.Resource, "role/") {
+			return nil, fmt.Errorf("RoleArn %s is not a valid IAM role ARN", t.RoleArn)
+		}
+	}
+
+	return t, nil
+}
+
+// PhysicalID takes req and id and returns id with the account and region of the Target
+// appended, so that moving a resource to another account or region replaces it.
+// If no Target is set id is returned as is, so existing physical IDs doesn't change.
+// Returns string.
+func PhysicalID(req *events.Request, id string) string {
+	t, err := ParseTarget(req.ResourceProperties)
+	if err != nil {
+		return id
+	}
+
+	if t.RoleArn != "" {
+		if a, err := arn.Parse(t.RoleArn); err == nil {
+			id = fmt.Sprintf("%s-%s", id, a.AccountID)
+		}
+	}
+	if t.Region != "" {
+		id = fmt.Sprintf("%s-%s", id, t.Region)
+	}
+
+	return id
+}
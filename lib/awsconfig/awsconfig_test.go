@@ -0,0 +1,89 @@
+package awsconfig
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/stscreds"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// Test that the Target is validated.
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		props string
+		err   bool
+	}{
+		{props: ``},
+		{props: `{"ClientName":"client"}`},
+		{props: `{"RoleArn":"arn:aws:iam::123456789012:role/custom-cf","ExternalId":"abc","Region":"us-east-1"}`},
+		{props: `{"Region":"eu-west-1"}`},
+		{props: `{"Region":"us-gov-west-1"}`},
+		{props: `{"Region":"Ireland"}`, err: true},
+		{props: `{"ExternalId":"abc"}`, err: true},
+		{props: `{"RoleArn":"custom-cf"}`, err: true},
+		{props: `{"RoleArn":"arn:aws:iam::123456789012:user/custom-cf"}`, err: true},
+		{props: `{"RoleArn":123}`, err: true},
+	}
+
+	for i, test := range tests {
+		_, err := ParseTarget([]byte(test.props))
+		if (err != nil) != test.err {
+			t.Errorf("Test number: %d failed. Expected error %t but got %v", i+1, test.err, err)
+		}
+	}
+}
+
+// Test that Apply sets region and credentials without changing cfg.
+func TestApply(t *testing.T) {
+	cfg := aws.Config{Region: "eu-west-1"}
+
+	target, err := Apply(cfg, []byte(`{"RoleArn":"arn:aws:iam::123456789012:role/custom-cf","ExternalId":"abc","Region":"us-east-1"}`))
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	if target.Region != "us-east-1" {
+		t.Errorf("Expected region us-east-1 but got %s", target.Region)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("Expected original cfg to be unchanged but got region %s", cfg.Region)
+	}
+
+	provider, ok := target.Credentials.(*stscreds.AssumeRoleProvider)
+	if !ok {
+		t.Fatalf("Expected credentials to be *stscreds.AssumeRoleProvider but got %T", target.Credentials)
+	}
+	if provider.RoleARN != "arn:aws:iam::123456789012:role/custom-cf" || aws.StringValue(provider.ExternalID) != "abc" {
+		t.Errorf("Expected role and external ID to be set but got %s and %s", provider.RoleARN, aws.StringValue(provider.ExternalID))
+	}
+
+	// No Target keeps the config as is.
+	same, err := Apply(cfg, []byte(`{"ClientName":"client"}`))
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+	if same.Region != "eu-west-1" || same.Credentials != nil {
+		t.Errorf("Expected config to be unchanged but got %+v", same)
+	}
+}
+
+// Test that the physical ID only changes when a Target is set.
+func TestPhysicalID(t *testing.T) {
+	tests := []struct {
+		props    string
+		expected string
+	}{
+		{props: `{"ClientName":"client"}`, expected: "id"},
+		{props: `{"Region":"us-east-1"}`, expected: "id-us-east-1"},
+		{props: `{"RoleArn":"arn:aws:iam::123456789012:role/custom-cf"}`, expected: "id-123456789012"},
+		{props: `{"RoleArn":"arn:aws:iam::123456789012:role/custom-cf","Region":"us-east-1"}`, expected: "id-123456789012-us-east-1"},
+	}
+
+	for i, test := range tests {
+		id := PhysicalID(&events.Request{ResourceProperties: []byte(test.props)}, "id")
+		if id != test.expected {
+			t.Errorf("Test number: %d failed. Wanted %s but got %s", i+1, test.expected, id)
+		}
+	}
+}
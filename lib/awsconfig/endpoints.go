@@ -0,0 +1,96 @@
+package awsconfig
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// endpointEnv is the environment variable that overrides the endpoint of all services.
+// The endpoint of a single service is overridden by adding its endpoint ID in upper case
+// with underscores, such as CUSTOM_CF_ENDPOINT_COGNITO_IDP for cognito-idp.
+const endpointEnv = "CUSTOM_CF_ENDPOINT"
+
+// allServices is the key in Endpoints that overrides the endpoint of all services.
+const allServices = "*"
+
+// Endpoints maps the endpoint ID of a service, such as cognito-idp, cognito-identity, iam
+// or sts, to the URL that its requests are sent to instead of AWS. The URL for the key "*"
+// is used for all services that aren't in the map. It's read from the environment variables
+// CUSTOM_CF_ENDPOINT and CUSTOM_CF_ENDPOINT_<SERVICE> and should only be used for running
+// against local emulators such as moto.
+var Endpoints = endpointsFromEnv(os.Environ())
+
+// endpointResolver sends the requests of the services in endpoints to their URL,
+// and leaves all other services to fallback.
+type endpointResolver struct {
+	endpoints map[string]string
+	fallback  aws.EndpointResolver
+}
+
+// ResolveEndpoint returns the endpoint of service in region.
+// Returns aws.Endpoint and error.
+func (r endpointResolver) ResolveEndpoint(service string, region string) (aws.Endpoint, error) {
+	u, ok := r.endpoints[service]
+	if !ok {
+		u, ok = r.endpoints[allServices]
+	}
+
+	if !ok {
+		return r.fallback.ResolveEndpoint(service, region)
+	}
+	return aws.ResolveWithEndpointURL(u).ResolveEndpoint(service, region)
+}
+
+// applyEndpoints takes cfg and points the services in Endpoints at their URL.
+// The SDK ignores the errors of resolvers, so the URLs are validated here.
+// Returns error.
+func applyEndpoints(cfg *aws.Config) error {
+	if len(Endpoints) == 0 {
+		return nil
+	}
+
+	services := []string{}
+	for service := range Endpoints {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		u, err := url.Parse(Endpoints[service])
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("Endpoint %s of %s needs to be an http or https URL", Endpoints[service], service)
+		}
+	}
+
+	cfg.EndpointResolver = endpointResolver{endpoints: Endpoints, fallback: cfg.EndpointResolver}
+	return nil
+}
+
+// endpointsFromEnv takes the environment env as key=value pairs and returns the endpoints in it.
+// Returns map[string]string.
+func endpointsFromEnv(env []string) map[string]string {
+	endpoints := map[string]string{}
+
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+
+		switch key := parts[0]; {
+		case key == endpointEnv:
+			endpoints[allServices] = parts[1]
+
+		case strings.HasPrefix(key, endpointEnv+"_"):
+			service := strings.ToLower(strings.Replace(strings.TrimPrefix(key, endpointEnv+"_"), "_", "-", -1))
+			endpoints[service] = parts[1]
+		}
+	}
+
+	return endpoints
+}
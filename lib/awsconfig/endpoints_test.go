@@ -0,0 +1,74 @@
+package awsconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Test that the endpoints are read from the environment.
+func TestEndpointsFromEnv(t *testing.T) {
+	tests := []struct {
+		env      []string
+		expected map[string]string
+	}{
+		{env: []string{"HOME=/root"}, expected: map[string]string{}},
+		{env: []string{"CUSTOM_CF_ENDPOINT=http://localhost:5000"}, expected: map[string]string{"*": "http://localhost:5000"}},
+		{
+			env:      []string{"CUSTOM_CF_ENDPOINT_COGNITO_IDP=http://localhost:5000", "CUSTOM_CF_ENDPOINT_IAM=http://localhost:5001"},
+			expected: map[string]string{"cognito-idp": "http://localhost:5000", "iam": "http://localhost:5001"},
+		},
+		{env: []string{"CUSTOM_CF_ENDPOINT_STS=", "CUSTOM_CF_ENDPOINTS=http://localhost:5000"}, expected: map[string]string{}},
+	}
+
+	for i, test := range tests {
+		if endpoints := endpointsFromEnv(test.env); !reflect.DeepEqual(endpoints, test.expected) {
+			t.Errorf("Test number: %d failed. Wanted %v but got %v", i+1, test.expected, endpoints)
+		}
+	}
+}
+
+// Test that services are sent to their endpoint, then to the endpoint of all services and last to AWS.
+func TestApplyEndpoints(t *testing.T) {
+	old := Endpoints
+	defer func() { Endpoints = old }()
+
+	tests := []struct {
+		endpoints map[string]string
+		service   string
+		expected  string
+		err       bool
+	}{
+		{endpoints: map[string]string{}, service: "iam", expected: "https://iam.amazonaws.com"},
+		{endpoints: map[string]string{"iam": "http://localhost:5000"}, service: "iam", expected: "http://localhost:5000"},
+		{endpoints: map[string]string{"iam": "http://localhost:5000"}, service: "sts", expected: "https://sts.amazonaws.com"},
+		{endpoints: map[string]string{"iam": "http://localhost:5000", "*": "http://localhost:5001"}, service: "sts", expected: "http://localhost:5001"},
+		{endpoints: map[string]string{"iam": "localhost:5000"}, err: true},
+		{endpoints: map[string]string{"*": "ftp://localhost"}, err: true},
+	}
+
+	for i, test := range tests {
+		Endpoints = test.endpoints
+
+		cfg := aws.Config{Region: "us-east-1", EndpointResolver: aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: "https://" + service + ".amazonaws.com", SigningRegion: region}, nil
+		})}
+
+		err := applyEndpoints(&cfg)
+		if (err != nil) != test.err {
+			t.Fatalf("Test number: %d failed. Expected error %t but got %v", i+1, test.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		endpoint, err := cfg.EndpointResolver.ResolveEndpoint(test.service, cfg.Region)
+		if err != nil {
+			t.Fatalf("Test number: %d failed. Got error %s", i+1, err.Error())
+		}
+		if endpoint.URL != test.expected || endpoint.SigningRegion != "us-east-1" {
+			t.Errorf("Test number: %d failed. Wanted %s in us-east-1 but got %s in %s", i+1, test.expected, endpoint.URL, endpoint.SigningRegion)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package awserrors classifies errors returned by the AWS SDK by their error code,
+// so that the custom resources doesn't need to match on the error messages that
+// AWS is free to change at any time. It also maps the errors to Reasons that
+// makes sense to show in the CloudFormation console.
+package awserrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+// Error codes returned by the AWS APIs used by the custom resources.
+const (
+	CodeResourceNotFound       = "ResourceNotFoundException"
+	CodeNoSuchEntity           = "NoSuchEntity" /* IAM's version of ResourceNotFoundException */
+	CodeTooManyRequests        = "TooManyRequestsException"
+	CodeThrottling             = "Throttling"
+	CodeThrottlingException    = "ThrottlingException"
+	CodeInvalidParameter       = "InvalidParameterException"
+	CodeInvalidInput           = "InvalidInput" /* IAM's version of InvalidParameterException */
+	CodeConcurrentModification = "ConcurrentModificationException"
+	CodeResourceExists         = "ResourceExistsException"
+)
+
+// reasons contains the friendly Reason for every error code.
+var reasons = map[string]string{
+	CodeResourceNotFound:       "The resource or one of the resources it depends on doesn't exist",
+	CodeNoSuchEntity:           "The resource or one of the resources it depends on doesn't exist",
+	CodeTooManyRequests:        "AWS throttled the requests, try again or create fewer resources at the same time",
+	CodeThrottling:             "AWS throttled the requests, try again or create fewer resources at the same time",
+	CodeThrottlingException:    "AWS throttled the requests, try again or create fewer resources at the same time",
+	CodeInvalidParameter:       "One or more properties has an invalid value",
+	CodeInvalidInput:           "One or more properties has an invalid value",
+	CodeConcurrentModification: "The resource was modified by someone else at the same time, try again",
+}
+
+// Code takes err and returns the AWS error code of it. err may be wrapped
+// with fmt.Errorf and %w. Empty string is returned if err isn't an AWS error.
+// Returns string.
+func Code(err error) string {
+	aerr := awserr.Error(nil)
+	if !errors.As(err, &aerr) {
+		return ""
+	}
+	return aerr.Code()
+}
+
+// IsNotFound returns true if err means that the requested resource doesn't exist.
+// Returns bool.
+func IsNotFound(err error) bool {
+	switch Code(err) {
+	case CodeResourceNotFound, CodeNoSuchEntity:
+		return true
+	}
+	return false
+}
+
+// IsThrottling returns true if err means that the request was throttled.
+// Returns bool.
+func IsThrottling(err error) bool {
+	switch Code(err) {
+	case CodeTooManyRequests, CodeThrottling, CodeThrottlingException:
+		return true
+	}
+	return false
+}
+
+// IsInvalidParameter returns true if err means that the request had an invalid parameter.
+// Returns bool.
+func IsInvalidParameter(err error) bool {
+	switch Code(err) {
+	case CodeInvalidParameter, CodeInvalidInput:
+		return true
+	}
+	return false
+}
+
+// IsConcurrentModification returns true if err means that the resource was modified
+// by another request at the same time.
+// Returns bool.
+func IsConcurrentModification(err error) bool {
+	return Code(err) == CodeConcurrentModification
+}
+
+// IsAlreadyExists returns true if err means that a resource with the same name already exists.
+// Returns bool.
+func IsAlreadyExists(err error) bool {
+	return Code(err) == CodeResourceExists
+}
+
+// Reason takes err and returns an error with a friendly description of the error code
+// in front of the original error, suitable as Reason for a FAILED response.
+// err is returned as is if it isn't an AWS error with a known code.
+// Returns error.
+func Reason(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	reason, ok := reasons[Code(err)]
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("%s. %w", reason, err)
+}
@@ -0,0 +1,76 @@
+package awserrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+)
+
+// Test that errors are classified by code, also when wrapped.
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err        error
+		code       string
+		notFound   bool
+		throttling bool
+		invalid    bool
+		concurrent bool
+		exists     bool
+	}{
+		{err: nil},
+		{err: fmt.Errorf("User pool client does not exist")},
+		{err: awserr.New(CodeResourceNotFound, "User pool client 123 does not exist.", nil), code: CodeResourceNotFound, notFound: true},
+		{err: awserr.NewRequestFailure(awserr.New(CodeNoSuchEntity, "role not found", nil), 404, "1"), code: CodeNoSuchEntity, notFound: true},
+		{err: fmt.Errorf("Failed to create Client. Error %w", awserr.New(CodeTooManyRequests, "Too many requests", nil)), code: CodeTooManyRequests, throttling: true},
+		{err: awserr.New(CodeThrottling, "Rate exceeded", nil), code: CodeThrottling, throttling: true},
+		{err: awserr.New(CodeInvalidParameter, "Invalid callback url", nil), code: CodeInvalidParameter, invalid: true},
+		{err: awserr.New(CodeConcurrentModification, "Modified", nil), code: CodeConcurrentModification, concurrent: true},
+		{err: awserr.New(CodeResourceExists, "The secret already exists", nil), code: CodeResourceExists, exists: true},
+		{err: awserr.New("InternalErrorException", "Internal error", nil), code: "InternalErrorException"},
+	}
+
+	for i, test := range tests {
+		switch {
+		case Code(test.err) != test.code:
+			t.Errorf("Test number: %d failed. Wanted code %s but got %s", i+1, test.code, Code(test.err))
+
+		case IsNotFound(test.err) != test.notFound:
+			t.Errorf("Test number: %d failed. Wanted IsNotFound %t", i+1, test.notFound)
+
+		case IsThrottling(test.err) != test.throttling:
+			t.Errorf("Test number: %d failed. Wanted IsThrottling %t", i+1, test.throttling)
+
+		case IsInvalidParameter(test.err) != test.invalid:
+			t.Errorf("Test number: %d failed. Wanted IsInvalidParameter %t", i+1, test.invalid)
+
+		case IsConcurrentModification(test.err) != test.concurrent:
+			t.Errorf("Test number: %d failed. Wanted IsConcurrentModification %t", i+1, test.concurrent)
+
+		case IsAlreadyExists(test.err) != test.exists:
+			t.Errorf("Test number: %d failed. Wanted IsAlreadyExists %t", i+1, test.exists)
+		}
+	}
+}
+
+// Test that known errors get a friendly Reason and that others are kept as is.
+func TestReason(t *testing.T) {
+	if Reason(nil) != nil {
+		t.Errorf("Expected nil Reason for nil error")
+	}
+
+	plain := fmt.Errorf("UserPoolId can't be empty")
+	if Reason(plain) != plain {
+		t.Errorf("Expected unknown errors to be returned as is but got %s", Reason(plain))
+	}
+
+	err := fmt.Errorf("Failed to create Client. Error %w", awserr.New(CodeTooManyRequests, "Too many requests", nil))
+	expected := "AWS throttled the requests, try again or create fewer resources at the same time. Failed to create Client. Error TooManyRequestsException: Too many requests"
+	reason := Reason(err)
+	if reason.Error() != expected {
+		t.Errorf("Wanted %s but got %s", expected, reason.Error())
+	}
+	if !IsThrottling(reason) {
+		t.Errorf("Expected the Reason to still be classified as throttling")
+	}
+}
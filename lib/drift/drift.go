@@ -0,0 +1,222 @@
+// Package drift compares the properties declared for a custom resource
+// against the live state of the resource in AWS. Since CloudFormation
+// drift detection doesn't cover custom resources this is the only way
+// to find changes made outside of CloudFormation.
+package drift
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Drift statuses, named the same as CloudFormation's own drift statuses.
+const (
+	StatusInSync     = "IN_SYNC"
+	StatusModified   = "MODIFIED"
+	StatusDeleted    = "DELETED"
+	StatusNotChecked = "NOT_CHECKED"
+)
+
+// Result is the result of comparing a declared resource against the live resource.
+type Result struct {
+	Status      string       `json:"Status"`
+	Differences []Difference `json:"Differences,omitempty"`
+}
+
+// Difference is a single property that differs between the declared and live resource.
+type Difference struct {
+	Property string      `json:"Property"`
+	Declared interface{} `json:"Declared"`
+	Live     interface{} `json:"Live"`
+}
+
+// Deleted returns a Result for a resource that doesn't exist anymore.
+// Returns *Result.
+func Deleted() *Result {
+	return &Result{Status: StatusDeleted}
+}
+
+// Compare takes declared and live which must be of the same struct type (or pointers to it)
+// and compares all fields that are set in declared. Fields that aren't declared are
+// not managed by the template and are therefore never reported.
+// Fields are named by their JSON tag and nested fields are joined with a dot.
+// Returns *Result.
+func Compare(declared interface{}, live interface{}) *Result {
+	diffs := []Difference{}
+	compare(reflect.ValueOf(declared), reflect.ValueOf(live), "", &diffs)
+
+	if len(diffs) == 0 {
+		return &Result{Status: StatusInSync}
+	}
+	return &Result{Status: StatusModified, Differences: diffs}
+}
+
+// compare takes declared and live and appends all differences found to diffs.
+func compare(declared reflect.Value, live reflect.Value, path string, diffs *[]Difference) {
+	// Nothing is declared so nothing to compare.
+	if isZero(declared) {
+		return
+	}
+
+	declared = indirect(declared)
+	live = indirect(live)
+
+	switch declared.Kind() {
+	case reflect.Struct:
+		t := declared.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := fieldName(t.Field(i))
+			if name == "" {
+				continue
+			}
+
+			liveField := reflect.Value{}
+			if live.IsValid() && live.Type() == t {
+				liveField = live.Field(i)
+			}
+			compare(declared.Field(i), liveField, join(path, name), diffs)
+		}
+
+	case reflect.Map:
+		// Only compare the keys that are declared, since AWS often adds keys of its own.
+		keys := declared.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		for _, key := range keys {
+			liveValue := reflect.Value{}
+			if live.IsValid() && live.Kind() == reflect.Map {
+				liveValue = live.MapIndex(key)
+			}
+			compare(declared.MapIndex(key), liveValue, join(path, fmt.Sprint(key)), diffs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		// The order of lists isn't significant for any of the resources.
+		if !equalSets(declared, live) {
+			*diffs = append(*diffs, Difference{Property: path, Declared: toInterface(declared), Live: toInterface(live)})
+		}
+
+	default:
+		if !equalScalars(declared, live) {
+			*diffs = append(*diffs, Difference{Property: path, Declared: toInterface(declared), Live: toInterface(live)})
+		}
+	}
+}
+
+// fieldName returns the JSON name of field. Empty string is returned
+// for unexported fields and fields that aren't part of the JSON.
+// Returns string.
+func fieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// equalSets returns true if the declared and live lists contain the same items.
+// Returns bool.
+func equalSets(declared reflect.Value, live reflect.Value) bool {
+	a, b := toStrings(declared), toStrings(live)
+	if len(a) != len(b) {
+		return false
+	}
+
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalScalars returns true if declared and live are equal. Since CloudFormation
+// sends all values as strings, values are compared by their string representation
+// and as booleans if both of them are booleans.
+// Returns bool.
+func equalScalars(declared reflect.Value, live reflect.Value) bool {
+	if !live.IsValid() {
+		return false
+	}
+
+	a, b := fmt.Sprint(declared.Interface()), fmt.Sprint(live.Interface())
+	if a == b {
+		return true
+	}
+
+	boolA, errA := strconv.ParseBool(a)
+	boolB, errB := strconv.ParseBool(b)
+	return errA == nil && errB == nil && boolA == boolB
+}
+
+// toStrings returns all items in the list v as strings.
+// Returns []string.
+func toStrings(v reflect.Value) []string {
+	l := []string{}
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return l
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		l = append(l, fmt.Sprint(indirect(v.Index(i)).Interface()))
+	}
+	return l
+}
+
+// toInterface returns v as interface{}. nil is returned if v isn't valid.
+// Returns interface{}.
+func toInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// indirect follows pointers and interfaces until a concrete value is reached.
+// Returns reflect.Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isZero returns true if v is not set.
+// Returns bool.
+func isZero(v reflect.Value) bool {
+	v = indirect(v)
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// join returns path and key joined with a dot.
+// Returns string.
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,81 @@
+package drift
+
+import (
+	"testing"
+)
+
+type testProps struct {
+	Name       string            `json:"Name"`
+	Enabled    string            `json:"Enabled,omitempty"`
+	List       []string          `json:"List,omitempty"`
+	Details    map[string]string `json:"Details,omitempty"`
+	Nested     *testNested       `json:"Nested,omitempty"`
+	Ignored    string            `json:"-"`
+	unexported string
+}
+
+type testNested struct {
+	Value  string `json:"Value"`
+	Number int64  `json:"Number"`
+}
+
+// Test that only declared fields are compared.
+func TestCompare(t *testing.T) {
+	declared := &testProps{
+		Name:    "client",
+		Enabled: "True",
+		List:    []string{"b", "a"},
+		Details: map[string]string{"key1": "value1"},
+		Nested:  &testNested{Value: "declared"},
+		Ignored: "ignored",
+	}
+	live := &testProps{
+		Name:    "client",
+		Enabled: "true",
+		List:    []string{"a", "b"},
+		Details: map[string]string{"key1": "value1", "added": "by aws"},
+		Nested:  &testNested{Value: "declared", Number: 10},
+	}
+
+	result := Compare(declared, live)
+	if result.Status != StatusInSync || len(result.Differences) != 0 {
+		t.Errorf("Expected %s but got %s with %+v", StatusInSync, result.Status, result.Differences)
+	}
+}
+
+// Test that all differing fields are reported in field order.
+func TestCompareModified(t *testing.T) {
+	declared := &testProps{
+		Name:    "client",
+		Enabled: "true",
+		List:    []string{"a"},
+		Details: map[string]string{"key2": "value2", "key1": "value1"},
+		Nested:  &testNested{Value: "declared", Number: 5},
+	}
+	live := &testProps{
+		Name:    "client",
+		Enabled: "false",
+		List:    []string{"a", "b"},
+		Details: map[string]string{"key2": "changed"},
+	}
+
+	result := Compare(declared, live)
+	if result.Status != StatusModified {
+		t.Errorf("Expected %s but got %s", StatusModified, result.Status)
+	}
+
+	expected := []string{"Enabled", "List", "Details.key1", "Details.key2", "Nested.Value", "Nested.Number"}
+	if len(result.Differences) != len(expected) {
+		t.Fatalf("Expected %d differences but got %+v", len(expected), result.Differences)
+	}
+
+	for i, diff := range result.Differences {
+		if diff.Property != expected[i] {
+			t.Errorf("Expected difference %d to be %s but got %s", i, expected[i], diff.Property)
+		}
+	}
+
+	if result.Differences[2].Live != nil {
+		t.Errorf("Expected live value of missing map key to be nil but got %v", result.Differences[2].Live)
+	}
+}
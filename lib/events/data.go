@@ -0,0 +1,244 @@
+package events
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Flatten takes data and returns it as the flat key value pairs that can be accessed
+// with Fn::GetAtt. data can be a map with string or number keys or a struct, with values of any type.
+//
+//   - Nested maps and structs are flattened with dots, so {"Foo": {"Bar": "x"}} becomes Foo.Bar.
+//   - Lists of strings, numbers and booleans are joined with commas so they can be used
+//     with Fn::Split. Lists that contain maps or structs get the index in the key, as Foo.0.Bar.
+//   - Struct fields are named by their json tag, fields with the tag "-" are left out.
+//   - nil values are left out, empty lists and maps are empty strings.
+//   - Values that implement encoding.TextMarshaler, such as time.Time, are used as text.
+//
+// Returns map[string]string and error.
+func Flatten(data interface{}) (map[string]string, error) {
+	v := indirect(reflect.ValueOf(data))
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Kind() != reflect.Map && v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Data needs to be a map or a struct, got %s", v.Type())
+	}
+
+	attr := map[string]string{}
+	if err := flatten(attr, "", v); err != nil {
+		return nil, err
+	}
+	return attr, nil
+}
+
+// flatten takes v and adds it to attr at key. Maps and structs are added with
+// their own keys prefixed by key.
+// Returns error.
+func flatten(attr map[string]string, key string, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if s, ok, err := scalar(v); ok || err != nil {
+		if err != nil {
+			return fmt.Errorf("Couldn't convert %s to a string. Error %s", key, err.Error())
+		}
+		return add(attr, key, s)
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Len() == 0 && key != "" {
+			return add(attr, key, "")
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			name, ok, err := scalar(indirect(iter.Key()))
+			if !ok || err != nil {
+				return fmt.Errorf("Keys of %s need to be strings or numbers, got %s", describe(key), iter.Key().Type())
+			}
+
+			if err := flatten(attr, join(key, name), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		return flattenStruct(attr, key, v)
+
+	case reflect.Slice, reflect.Array:
+		return flattenList(attr, key, v)
+	}
+
+	return fmt.Errorf("%s has the unsupported type %s", describe(key), v.Type())
+}
+
+// flattenStruct takes the struct v and adds its exported fields to attr with key as prefix.
+// Fields of embedded structs are added as if they were fields of v, as with encoding/json.
+// Returns error.
+func flattenStruct(attr map[string]string, key string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, omitEmpty := field.Name, false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" && len(parts) == 1 {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitEmpty = omitEmpty || opt == "omitempty"
+			}
+		}
+
+		val := v.Field(i)
+		if omitEmpty && val.IsZero() {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened into v.
+		if field.Anonymous && indirect(val).Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if indirect(val).IsValid() {
+				if err := flattenStruct(attr, key, indirect(val)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if err := flatten(attr, join(key, name), val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenList takes the slice or array v and adds it to attr at key. A list of scalars is
+// joined with commas, otherwise every item is added with its index in the key.
+// Returns error.
+func flattenList(attr map[string]string, key string, v reflect.Value) error {
+	items, joinable := []string{}, true
+	for i := 0; i < v.Len(); i++ {
+		item := indirect(v.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+
+		s, ok, err := scalar(item)
+		if err != nil {
+			return fmt.Errorf("Couldn't convert %s to a string. Error %s", join(key, strconv.Itoa(i)), err.Error())
+		}
+		if !ok {
+			joinable = false
+			break
+		}
+		items = append(items, s)
+	}
+
+	if joinable {
+		return add(attr, key, strings.Join(items, ","))
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := flatten(attr, join(key, strconv.Itoa(i)), v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scalar takes v and returns it as a string if it's a string, number, boolean or
+// implements encoding.TextMarshaler. ok is false for all other values.
+// Returns string, bool and error.
+func scalar(v reflect.Value) (string, bool, error) {
+	if v.CanInterface() {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			b, err := m.MarshalText()
+			return string(b), true, err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true, nil
+	}
+
+	return "", false, nil
+}
+
+// indirect returns the value that v points to or contains. The returned value
+// isn't valid if v is a nil pointer or interface.
+// Returns reflect.Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// add sets key to val in attr. Two values can't have the same key, such as
+// {"Foo.Bar": "x"} and {"Foo": {"Bar": "y"}}.
+// Returns error.
+func add(attr map[string]string, key string, val string) error {
+	if key == "" {
+		return fmt.Errorf("Data needs to be a map or a struct")
+	}
+	if _, ok := attr[key]; ok {
+		return fmt.Errorf("Data has more than one value for %s", key)
+	}
+
+	attr[key] = val
+	return nil
+}
+
+// join returns key and name joined with a dot.
+// Returns string.
+func join(key string, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+// describe returns key for use in error messages.
+// Returns string.
+func describe(key string) string {
+	if key == "" {
+		return "Data"
+	}
+	return key
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testDomain struct {
+	testCloudFront
+	Domain   string        `json:"Domain"`
+	Version  *string       `json:"Version"`
+	Aliases  []string      `json:"Aliases"`
+	Origins  []*testOrigin `json:"Origins"`
+	Tags     map[string]int
+	Created  time.Time `json:"Created"`
+	internal string
+	Skipped  string `json:"-"`
+	Optional string `json:"Optional,omitempty"`
+}
+
+type testCloudFront struct {
+	CloudFrontDomain string `json:"CloudFrontDomain"`
+}
+
+type testOrigin struct {
+	ID   string `json:"Id"`
+	Port int    `json:"Port"`
+}
+
+// Test that data is flattened to Fn::GetAtt keys.
+func TestFlatten(t *testing.T) {
+	version := "1"
+
+	tests := []struct {
+		data     interface{}
+		expected map[string]string
+		err      bool
+	}{
+		{data: nil, expected: nil},
+		{data: (*testDomain)(nil), expected: nil},
+		{data: map[string]string{"key1": "value1"}, expected: map[string]string{"key1": "value1"}},
+		{data: map[string]string{}, expected: map[string]string{}},
+		{
+			data: map[string]interface{}{
+				"Int":     42,
+				"Float":   0.5,
+				"Bool":    true,
+				"Nil":     nil,
+				"Empty":   []string{},
+				"List":    []interface{}{"a", 1, false},
+				"Nested":  map[string]interface{}{"Foo": map[string]string{"Bar": "x"}},
+				"Objects": []map[string]string{{"Name": "a"}, {"Name": "b"}},
+			},
+			expected: map[string]string{
+				"Int":            "42",
+				"Float":          "0.5",
+				"Bool":           "true",
+				"Empty":          "",
+				"List":           "a,1,false",
+				"Nested.Foo.Bar": "x",
+				"Objects.0.Name": "a",
+				"Objects.1.Name": "b",
+			},
+		},
+		{
+			data: &testDomain{
+				testCloudFront: testCloudFront{CloudFrontDomain: "d111111abcdef8.cloudfront.net"},
+				Domain:         "auth.example.com",
+				Version:        &version,
+				Aliases:        []string{"a.example.com", "b.example.com"},
+				Origins:        []*testOrigin{{ID: "s3", Port: 443}, nil},
+				Tags:           map[string]int{"Weight": 10},
+				Created:        time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+				internal:       "internal",
+				Skipped:        "skipped",
+			},
+			expected: map[string]string{
+				"CloudFrontDomain": "d111111abcdef8.cloudfront.net",
+				"Domain":           "auth.example.com",
+				"Version":          "1",
+				"Aliases":          "a.example.com,b.example.com",
+				"Origins.0.Id":     "s3",
+				"Origins.0.Port":   "443",
+				"Tags.Weight":      "10",
+				"Created":          "2019-01-02T03:04:05Z",
+			},
+		},
+		{data: map[string]interface{}{"Foo.Bar": "x", "Foo": map[string]string{"Bar": "y"}}, err: true},
+		{data: map[int]string{1: "x"}, expected: map[string]string{"1": "x"}},
+		{data: map[testOrigin]string{{ID: "s3"}: "x"}, err: true},
+		{data: map[string]interface{}{"Func": func() {}}, err: true},
+		{data: "value", err: true},
+		{data: []string{"a"}, err: true},
+	}
+
+	for i, test := range tests {
+		attr, err := Flatten(test.data)
+		switch {
+		case (err != nil) != test.err:
+			t.Errorf("Test number: %d failed. Expected error %t but got %v", i+1, test.err, err)
+
+		case !reflect.DeepEqual(attr, test.expected):
+			t.Errorf("Test number: %d failed. Wanted %v but got %v", i+1, test.expected, attr)
+		}
+	}
+}
+
+// Test that the flattened data is the same every time.
+func TestFlattenDeterministic(t *testing.T) {
+	data := map[string]interface{}{
+		"A": []map[string]interface{}{{"B": []string{"1", "2"}, "C": map[string]string{"D": "3"}}},
+		"E": map[string][]int{"F": {4, 5}},
+	}
+
+	first, err := Flatten(data)
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	for i := 0; i < 20; i++ {
+		attr, err := Flatten(data)
+		if err != nil || !reflect.DeepEqual(attr, first) {
+			t.Fatalf("Test number: %d failed. Wanted %v but got %v and error %v", i+1, first, attr, err)
+		}
+	}
+}
+
+// Test that data that can't be flattened fails the response instead of not responding at all.
+func TestCreateResponseInvalidData(t *testing.T) {
+	req := &Request{StackID: "stack1", RequestID: "1234", LogicalResourceID: "resource1"}
+
+	b, err := req.createResponse("testId1", map[string]interface{}{"Func": func() {}}, nil)
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	expected := `{"Status":"FAILED","Reason":"Couldn't create the data of the response. Error Func has the unsupported type func()","PhysicalResourceId":"testId1","StackId":"stack1","RequestId":"1234","LogicalResourceId":"resource1"}`
+	if string(b) != expected {
+		t.Errorf("Wanted %s but got %s", expected, string(b))
+	}
+}
@@ -5,6 +5,7 @@ package events
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,6 +25,13 @@ type Request struct {
 	PhysicalResourceID    string          `json:"PhysicalResourceId,omitempty"`
 	ResourceProperties    json.RawMessage `json:"ResourceProperties,omitempty"`
 	OldResourceProperties json.RawMessage `json:"OldResourceProperties,omitempty"`
+
+	// Stack is the parsed StackID, it is set by Validate.
+	Stack *Stack `json:"-"`
+
+	// Lambda is the invocation that handles the request, see LambdaFromContext.
+	// If it's set the Reason of FAILED responses contains its request ID and logs.
+	Lambda *Lambda `json:"-"`
 }
 
 // Response is the data that will be stored on the pre-signed S3 url.
@@ -77,15 +85,29 @@ func (req *Request) Unmarshal(new interface{}, old interface{}) error {
 }
 
 // Send takes physicalID and respError and sends it to an S3 pre-signed url.
+// It's the same as SendWithContext without a context.
+// Returns error.
+func (req *Request) Send(physicalID string, data interface{}, respErr error) error {
+	return req.SendWithContext(context.Background(), physicalID, data, respErr)
+}
+
+// SendWithContext takes ctx, physicalID and respError and sends it to an S3 pre-signed url.
 // physicalID should be a unique physicalID that the resource should have, naming will
 // depend on the type of resource you're creating but can often be "put together" by
 // various fields from ResourceProperties.
-// data should be key value pairs of data that you want to be able to access with the
-// Fn::GetAtt function in the CloudFormation template.
+// data is the data that you want to be able to access with the Fn::GetAtt function in the
+// CloudFormation template. It can be a map[string]string or any map or struct that Flatten
+// accepts, such as a struct with lists in it.
 // respErr is the response error, if the resource creation failed we still need to save
 // the state FAILED to S3 for the Custom Resource to work.
+// Sending is aborted when ctx is done, use the context of the lambda function so that
+// there is as much time as possible to respond.
 // Returns error.
-func (req *Request) Send(physicalID string, data map[string]string, respErr error) error {
+func (req *Request) SendWithContext(ctx context.Context, physicalID string, data interface{}, respErr error) error {
+	if req.Lambda == nil {
+		req.Lambda = LambdaFromContext(ctx)
+	}
+
 	// Create Response.
 	body, err := req.createResponse(physicalID, data, respErr)
 	if err != nil {
@@ -93,7 +115,7 @@ func (req *Request) Send(physicalID string, data map[string]string, respErr erro
 	}
 
 	// Send the response.
-	if err := req.sendResponse(body, 30000); err != nil {
+	if err := req.sendResponse(ctx, body, 30000); err != nil {
 		return err
 	}
 
@@ -101,9 +123,10 @@ func (req *Request) Send(physicalID string, data map[string]string, respErr erro
 }
 
 // createResponse takes physicalID, data and err and creates a response JSON bytes that can be sent to the pre-signed s3 url.
-// Where data is key value pairs that you want to be accessed through Fn::GetAtt, can be nil if not needed.
+// Where data is flattened to the key value pairs that you want to be accessed through Fn::GetAtt, can be nil if not needed.
+// If data can't be flattened the response is FAILED, so that CloudFormation isn't left waiting.
 // Returns []byte and error.
-func (req *Request) createResponse(physicalID string, data map[string]string, respErr error) ([]byte, error) {
+func (req *Request) createResponse(physicalID string, data interface{}, respErr error) ([]byte, error) {
 	resp := &response{
 		Status:             "SUCCESS",
 		StackID:            req.StackID,
@@ -112,18 +135,47 @@ func (req *Request) createResponse(physicalID string, data map[string]string, re
 		LogicalResourceID:  req.LogicalResourceID,
 	}
 
+	attr, err := Flatten(data)
+	if err != nil && respErr == nil {
+		respErr = fmt.Errorf("Couldn't create the data of the response. Error %s", err.Error())
+	}
+
 	// Set Reason only if we got a resource create error.
 	// And only set data if resource creation was successfull.
+	message, suffix := "", ""
 	switch {
 	case respErr != nil:
 		resp.Status = "FAILED"
-		resp.Reason = respErr.Error()
+		message = respErr.Error()
+		if req.Lambda != nil {
+			suffix = req.Lambda.reason()
+		}
+		resp.Reason = message + suffix
 
 	default:
-		resp.Data = data
+		resp.Data = attr
 	}
 
 	b, err := json.Marshal(resp)
+
+	// Find the longest error message that fits, the suffix pointing to the logs is always kept.
+	// The message can grow when it's escaped, so the fit is searched for instead of calculated.
+	if err == nil && len(b) > maxResponseSize {
+		low, high := 0, len(message)
+		for low < high {
+			mid := (low + high + 1) / 2
+			resp.Reason = truncate(message, mid) + suffix
+			if b, err = json.Marshal(resp); err == nil && len(b) <= maxResponseSize {
+				low = mid
+				continue
+			}
+			high = mid - 1
+		}
+
+		resp.Reason = truncate(message, low) + suffix
+		b, err = json.Marshal(resp)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't JSON Marshal the Response. Error %s", err.Error())
 	}
@@ -132,9 +184,9 @@ func (req *Request) createResponse(physicalID string, data map[string]string, re
 }
 
 // sendResponse created a response for the s3-presigned-url and sends it.
-// It sets the http client timeout to timeOut in milliseconds.
+// It sets the http client timeout to timeOut in milliseconds and stops when ctx is done.
 // Returns error.
-func (req *Request) sendResponse(body []byte, timeOut int) error {
+func (req *Request) sendResponse(ctx context.Context, body []byte, timeOut int) error {
 	client := &http.Client{Timeout: time.Duration(timeOut) * time.Millisecond}
 
 	switch {
@@ -146,11 +198,16 @@ func (req *Request) sendResponse(body []byte, timeOut int) error {
 	}
 
 	// Create the request for s3-presigned-url.
-	saveReq, err := http.NewRequest("PUT", req.ResponseURL, bytes.NewBuffer(body))
+	saveReq, err := http.NewRequestWithContext(ctx, "PUT", req.ResponseURL, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("Couldn't create request for s3-presigned-url. Error %s", err.Error())
 	}
 
+	// Never send the response anywhere else than to a pre-signed S3 URL.
+	if err := req.CheckResponseURL(); err != nil {
+		return fmt.Errorf("Refusing to send response. Error %s", err.Error())
+	}
+
 	// Send request for s3-presigned-url.
 	return req.doRequest(client, saveReq, 0)
 }
@@ -169,10 +226,20 @@ func (req *Request) doRequest(client *http.Client, saveReq *http.Request, attemp
 		err = fmt.Errorf("Didn't receive error, but response wasn't 200. Status Code: %d. Attempt %d", resp.StatusCode, attempt+1)
 	}
 
+	if resp != nil {
+		resp.Body.Close()
+	}
+
 	// If error is nit nil and we haven't retried 5 times test again.
-	// Otherwise return error.
+	// Otherwise return error. There is no point in retrying once the context is done.
 	switch {
-	case err != nil && attempt < 4:
+	case err != nil && attempt < 4 && saveReq.Context().Err() == nil:
+		// The body was read by the previous attempt.
+		if saveReq.GetBody != nil {
+			if saveReq.Body, err = saveReq.GetBody(); err != nil {
+				return fmt.Errorf("Couldn't reset the body of the request for s3-presigned-url. Error %s", err.Error())
+			}
+		}
 		return req.doRequest(client, saveReq, attempt+1)
 
 	case err != nil:
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// maxResponseSize is the largest response in bytes that CloudFormation accepts.
+const maxResponseSize = 4096
+
+// Lambda is the invocation of the lambda function that handles a request. It's added
+// to the Reason of FAILED responses and to the log lines, so that a failed stack event
+// can be matched to its logs.
+type Lambda struct {
+	RequestID string `json:"awsRequestId,omitempty"` // The request ID of the invocation.
+	LogGroup  string `json:"logGroup,omitempty"`     // Such as /aws/lambda/cognito-userpool-client.
+	LogStream string `json:"logStream,omitempty"`    // Such as 2019/01/22/[$LATEST]8a1b4f0c7d.
+	Region    string `json:"-"`                      // The region that the function runs in.
+}
+
+// LambdaFromContext returns the invocation from the lambda context in ctx and the
+// environment of the function. nil is returned when not running in lambda.
+// Returns *Lambda.
+func LambdaFromContext(ctx context.Context) *Lambda {
+	l := &Lambda{
+		LogGroup:  lambdacontext.LogGroupName,
+		LogStream: lambdacontext.LogStreamName,
+		Region:    os.Getenv("AWS_REGION"),
+	}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		l.RequestID = lc.AwsRequestID
+	}
+
+	if l.RequestID == "" && l.LogGroup == "" {
+		return nil
+	}
+	return l
+}
+
+// LogURL returns the url of the log stream in the CloudWatch console.
+// An empty string is returned if the log group or stream isn't known.
+// Returns string.
+func (l *Lambda) LogURL() string {
+	if l.LogGroup == "" || l.LogStream == "" || l.Region == "" {
+		return ""
+	}
+
+	domain := "console.aws.amazon.com"
+	switch {
+	case strings.HasPrefix(l.Region, "cn-"):
+		domain = "console.amazonaws.cn"
+
+	case strings.HasPrefix(l.Region, "us-gov-"):
+		domain = "console.amazonaws-us-gov.com"
+	}
+
+	// The console takes the names as they are, only the separators of the fragment need escaping.
+	escape := strings.NewReplacer(";", "%3B", "#", "%23", " ", "%20").Replace
+	return fmt.Sprintf("https://%[1]s.%[2]s/cloudwatch/home?region=%[1]s#logEventViewer:group=%[3]s;stream=%[4]s",
+		l.Region, domain, escape(l.LogGroup), escape(l.LogStream))
+}
+
+// reason returns the suffix that is added to the Reason of FAILED responses.
+// Returns string.
+func (l *Lambda) reason() string {
+	parts := []string{}
+	if l.RequestID != "" {
+		parts = append(parts, "RequestId: "+l.RequestID)
+	}
+
+	switch u := l.LogURL(); {
+	case u != "":
+		parts = append(parts, "Logs: "+u)
+
+	case l.LogGroup != "":
+		parts = append(parts, "LogGroup: "+l.LogGroup)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// truncate returns s cut to at most n bytes, without splitting a character.
+// "..." is added at the end if s was cut, an empty string is returned if there's no room for it.
+// Returns string.
+func truncate(s string, n int) string {
+	switch {
+	case len(s) <= n:
+		return s
+
+	case n <= len("..."):
+		return ""
+	}
+
+	n -= len("...")
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// Test that the invocation is read from the lambda context and environment.
+func TestLambdaFromContext(t *testing.T) {
+	oldGroup, oldStream := lambdacontext.LogGroupName, lambdacontext.LogStreamName
+	defer func() { lambdacontext.LogGroupName, lambdacontext.LogStreamName = oldGroup, oldStream }()
+
+	lambdacontext.LogGroupName, lambdacontext.LogStreamName = "", ""
+	if l := LambdaFromContext(context.Background()); l != nil {
+		t.Errorf("Expected nil outside of lambda but got %+v", l)
+	}
+
+	lambdacontext.LogGroupName, lambdacontext.LogStreamName = "/aws/lambda/fn", "stream"
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "abc"})
+	l := LambdaFromContext(ctx)
+	if l == nil || l.RequestID != "abc" || l.LogGroup != "/aws/lambda/fn" || l.LogStream != "stream" {
+		t.Errorf("Unexpected invocation %+v", l)
+	}
+}
+
+// Test that the log link is made for the console of the partition.
+func TestLogURL(t *testing.T) {
+	tests := []struct {
+		lambda   Lambda
+		expected string
+	}{
+		{
+			lambda:   Lambda{Region: "eu-west-1", LogGroup: "/aws/lambda/fn", LogStream: "2019/01/22/[$LATEST]abc"},
+			expected: "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logEventViewer:group=/aws/lambda/fn;stream=2019/01/22/[$LATEST]abc",
+		},
+		{
+			lambda:   Lambda{Region: "cn-north-1", LogGroup: "/aws/lambda/fn", LogStream: "a;b"},
+			expected: "https://cn-north-1.console.amazonaws.cn/cloudwatch/home?region=cn-north-1#logEventViewer:group=/aws/lambda/fn;stream=a%3Bb",
+		},
+		{
+			lambda:   Lambda{Region: "us-gov-west-1", LogGroup: "/aws/lambda/fn", LogStream: "s"},
+			expected: "https://us-gov-west-1.console.amazonaws-us-gov.com/cloudwatch/home?region=us-gov-west-1#logEventViewer:group=/aws/lambda/fn;stream=s",
+		},
+		{lambda: Lambda{Region: "eu-west-1", LogGroup: "/aws/lambda/fn"}, expected: ""},
+	}
+
+	for i, test := range tests {
+		if u := test.lambda.LogURL(); u != test.expected {
+			t.Errorf("Test number: %d failed. Wanted %s but got %s", i+1, test.expected, u)
+		}
+	}
+}
+
+// Test that FAILED responses contain the invocation and are never larger than CloudFormation accepts.
+func TestCreateResponseLambda(t *testing.T) {
+	l := &Lambda{RequestID: "abc", Region: "eu-west-1", LogGroup: "/aws/lambda/fn", LogStream: "stream"}
+	suffix := " [RequestId: abc, Logs: https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logEventViewer:group=/aws/lambda/fn;stream=stream]"
+
+	tests := []struct {
+		lambda *Lambda
+		err    error
+		data   map[string]string
+		reason string
+		cut    bool
+	}{
+		{lambda: l, err: fmt.Errorf("Failed"), reason: "Failed" + suffix},
+		{lambda: &Lambda{RequestID: "abc"}, err: fmt.Errorf("Failed"), reason: "Failed [RequestId: abc]"},
+		{lambda: &Lambda{LogGroup: "/aws/lambda/fn"}, err: fmt.Errorf("Failed"), reason: "Failed [LogGroup: /aws/lambda/fn]"},
+		{lambda: nil, err: fmt.Errorf("Failed"), reason: "Failed"},
+		{lambda: l, data: map[string]string{"key1": "value1"}, reason: ""},
+		{lambda: l, err: errors.New(strings.Repeat("<ö>", 2000)), cut: true},
+	}
+
+	for i, test := range tests {
+		req := &Request{StackID: "stack1", RequestID: "1234", LogicalResourceID: "resource1", Lambda: test.lambda}
+
+		b, err := req.createResponse("testId1", test.data, test.err)
+		if err != nil {
+			t.Fatalf("Test number: %d failed. Got error %s", i+1, err.Error())
+		}
+
+		resp := &response{}
+		if err := json.Unmarshal(b, resp); err != nil {
+			t.Fatalf("Test number: %d failed. Got error %s", i+1, err.Error())
+		}
+
+		switch {
+		case len(b) > maxResponseSize:
+			t.Errorf("Test number: %d failed. Response is %d bytes", i+1, len(b))
+
+		case test.cut && (!strings.HasSuffix(resp.Reason, "..."+suffix) || !strings.HasPrefix(resp.Reason, "<ö>")):
+			t.Errorf("Test number: %d failed. Expected a shortened message but got %s", i+1, resp.Reason)
+
+		case !test.cut && resp.Reason != test.reason:
+			t.Errorf("Test number: %d failed. Wanted %s but got %s", i+1, test.reason, resp.Reason)
+		}
+	}
+}
+
+// Test that strings are shortened without splitting characters.
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		n        int
+		expected string
+	}{
+		{s: "abc", n: 3, expected: "abc"},
+		{s: "abcdef", n: 5, expected: "ab..."},
+		{s: "aöbcd", n: 5, expected: "a..."},
+		{s: "abcdef", n: 3, expected: ""},
+	}
+
+	for i, test := range tests {
+		if s := truncate(test.s, test.n); s != test.expected {
+			t.Errorf("Test number: %d failed. Wanted %q but got %q", i+1, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// allowedResponseURLsEnv is the environment variable that can contain a comma separated
+// list of extra URLs that responses may be sent to, for example a local stand-in for S3.
+const allowedResponseURLsEnv = "CUSTOM_CF_ALLOWED_RESPONSE_URLS"
+
+// AllowedResponseURLs contains URLs that ResponseURL is allowed to start with even though
+// they aren't pre-signed S3 URLs. It's read from CUSTOM_CF_ALLOWED_RESPONSE_URLS and
+// should only be used for tests and local stand-ins.
+var AllowedResponseURLs = allowedFromEnv()
+
+// s3HostRegexp matches the virtual hosted and path style S3 endpoints,
+// with the region (if any) in the first capture group and the domain in the second.
+// Examples: bucket.s3.amazonaws.com, bucket.s3-eu-west-1.amazonaws.com,
+// s3.eu-west-1.amazonaws.com and bucket.s3.cn-north-1.amazonaws.com.cn.
+var s3HostRegexp = regexp.MustCompile(`^(?:[a-z0-9][a-z0-9.-]*\.)?s3(?:[.-](?:dualstack\.)?([a-z]{2}(?:-[a-z]+)+-\d))?\.(amazonaws\.com(?:\.cn)?)$`)
+
+// CheckResponseURL returns an error if ResponseURL isn't an HTTPS pre-signed S3 URL in
+// the region (or partition for global endpoints) that the lambda function is running in.
+// Anyone that can invoke the function can set ResponseURL, so a request that fails this
+// check must never be answered.
+// Returns error.
+func (req *Request) CheckResponseURL() error {
+	u, err := url.Parse(req.ResponseURL)
+	if err != nil {
+		return fmt.Errorf("ResponseURL isn't a valid URL. Error %s", err.Error())
+	}
+
+	if allowed(u) {
+		return nil
+	}
+
+	switch {
+	case u.Scheme != "https":
+		return fmt.Errorf("ResponseURL %s must use https", u.Redacted())
+
+	case u.User != nil:
+		return fmt.Errorf("ResponseURL %s can't contain user info", u.Redacted())
+
+	case u.Port() != "":
+		return fmt.Errorf("ResponseURL %s can't contain a port", u.Redacted())
+	}
+
+	match := s3HostRegexp.FindStringSubmatch(strings.ToLower(u.Hostname()))
+	if match == nil {
+		return fmt.Errorf("ResponseURL host %s isn't an S3 endpoint", u.Hostname())
+	}
+
+	region := os.Getenv("AWS_REGION")
+	switch {
+	case match[1] != "" && region != "" && match[1] != region:
+		return fmt.Errorf("ResponseURL is in region %s but expected %s", match[1], region)
+
+	case match[2] != partitionDomain(region):
+		return fmt.Errorf("ResponseURL domain %s isn't in the partition of region %s", match[2], region)
+	}
+
+	// Pre-signed URLs are signed with either signature version 4 or 2.
+	query := u.Query()
+	if query.Get("X-Amz-Signature") == "" && query.Get("Signature") == "" {
+		return fmt.Errorf("ResponseURL isn't a pre-signed URL")
+	}
+
+	return nil
+}
+
+// allowed returns true if u starts with any of AllowedResponseURLs.
+// Returns bool.
+func allowed(u *url.URL) bool {
+	for _, a := range AllowedResponseURLs {
+		prefix, err := url.Parse(a)
+		if err != nil {
+			continue
+		}
+
+		if u.Scheme == prefix.Scheme && u.User == nil && u.Host == prefix.Host && strings.HasPrefix(u.Path, prefix.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedFromEnv returns the URLs in CUSTOM_CF_ALLOWED_RESPONSE_URLS.
+// Returns []string.
+func allowedFromEnv() []string {
+	l := []string{}
+	for _, a := range strings.Split(os.Getenv(allowedResponseURLsEnv), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			l = append(l, a)
+		}
+	}
+	return l
+}
+
+// partitionDomain returns the S3 domain for the partition of region.
+// Returns string.
+func partitionDomain(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// The RequestTypes that CloudFormation sends.
+const (
+	RequestCreate = "Create"
+	RequestUpdate = "Update"
+	RequestDelete = "Delete"
+)
+
+// Stack contains the parts of a requests StackId.
+type Stack struct {
+	Partition string
+	Region    string
+	AccountID string
+	Name      string
+	ID        string // The unique ID at the end of the StackId.
+}
+
+// Validate checks that all fields that CloudFormation needs to accept the response are set and that
+// RequestType is one of Create, Update or Delete. RequestType is normalised to the casing
+// CloudFormation uses, so "CREATE" becomes "Create". StackId is parsed and set to req.Stack.
+// Returns error.
+func (req *Request) Validate() error {
+	switch strings.ToLower(req.RequestType) {
+	case "create":
+		req.RequestType = RequestCreate
+
+	case "update":
+		req.RequestType = RequestUpdate
+
+	case "delete":
+		req.RequestType = RequestDelete
+
+	default:
+		return fmt.Errorf("RequestType %s is not one of %s, %s or %s", req.RequestType, RequestCreate, RequestUpdate, RequestDelete)
+	}
+
+	switch {
+	case req.StackID == "":
+		return fmt.Errorf("StackId can't be empty")
+
+	case req.RequestID == "":
+		return fmt.Errorf("RequestId can't be empty")
+
+	case req.LogicalResourceID == "":
+		return fmt.Errorf("LogicalResourceId can't be empty")
+
+	case req.ResourceType == "":
+		return fmt.Errorf("ResourceType can't be empty")
+
+	case req.PhysicalResourceID == "" && req.RequestType != RequestCreate:
+		return fmt.Errorf("PhysicalResourceId can't be empty on %s", req.RequestType)
+	}
+
+	stack, err := ParseStackID(req.StackID)
+	if err != nil {
+		return err
+	}
+	req.Stack = stack
+
+	return nil
+}
+
+// ParseStackID takes id in the format arn:partition:cloudformation:region:account:stack/name/id
+// and returns its parts.
+// Returns *Stack and error.
+func ParseStackID(id string) (*Stack, error) {
+	a, err := arn.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("StackId %s is not a valid ARN. Error %s", id, err.Error())
+	}
+
+	parts := strings.Split(a.Resource, "/")
+	if a.Service != "cloudformation" || len(parts) != 3 || parts[0] != "stack" || parts[1] == "" {
+		return nil, fmt.Errorf("StackId %s is not a valid stack ARN", id)
+	}
+
+	return &Stack{
+		Partition: a.Partition,
+		Region:    a.Region,
+		AccountID: a.AccountID,
+		Name:      parts[1],
+		ID:        parts[2],
+	}, nil
+}
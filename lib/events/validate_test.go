@@ -0,0 +1,60 @@
+package events
+
+import (
+	"testing"
+)
+
+// Test that the envelope is validated and RequestType normalised.
+func TestValidate(t *testing.T) {
+	stackID := "arn:aws:cloudformation:eu-west-1:123456789012:stack/my-stack/1a2b3c4d-5e6f"
+
+	tests := []struct {
+		req         Request
+		requestType string
+		err         bool
+	}{
+		{req: Request{RequestType: "CREATE", StackID: stackID, RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test"}, requestType: "Create"},
+		{req: Request{RequestType: "update", StackID: stackID, RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test", PhysicalResourceID: "id"}, requestType: "Update"},
+		{req: Request{RequestType: "Delete", StackID: stackID, RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test", PhysicalResourceID: "id"}, requestType: "Delete"},
+		{req: Request{RequestType: "Replace", StackID: stackID, RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test"}, err: true},
+		{req: Request{RequestType: "Create", RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test"}, err: true},
+		{req: Request{RequestType: "Create", StackID: stackID, LogicalResourceID: "Client", ResourceType: "Custom::Test"}, err: true},
+		{req: Request{RequestType: "Create", StackID: stackID, RequestID: "1", ResourceType: "Custom::Test"}, err: true},
+		{req: Request{RequestType: "Create", StackID: stackID, RequestID: "1", LogicalResourceID: "Client"}, err: true},
+		{req: Request{RequestType: "Delete", StackID: stackID, RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test"}, err: true},
+		{req: Request{RequestType: "Create", StackID: "stack1", RequestID: "1", LogicalResourceID: "Client", ResourceType: "Custom::Test"}, err: true},
+	}
+
+	for i, test := range tests {
+		err := test.req.Validate()
+		switch {
+		case (err != nil) != test.err:
+			t.Errorf("Test number: %d failed. Expected error %t but got %v", i+1, test.err, err)
+
+		case err == nil && test.req.RequestType != test.requestType:
+			t.Errorf("Test number: %d failed. Wanted RequestType %s but got %s", i+1, test.requestType, test.req.RequestType)
+
+		case err == nil && test.req.Stack.Name != "my-stack":
+			t.Errorf("Test number: %d failed. Wanted stack name my-stack but got %s", i+1, test.req.Stack.Name)
+		}
+	}
+}
+
+// Test that StackId is parsed into its parts.
+func TestParseStackID(t *testing.T) {
+	stack, err := ParseStackID("arn:aws-cn:cloudformation:cn-north-1:123456789012:stack/my-stack/1a2b3c4d-5e6f")
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	expected := Stack{Partition: "aws-cn", Region: "cn-north-1", AccountID: "123456789012", Name: "my-stack", ID: "1a2b3c4d-5e6f"}
+	if *stack != expected {
+		t.Errorf("Wanted %+v but got %+v", expected, *stack)
+	}
+
+	for _, id := range []string{"", "my-stack", "arn:aws:s3:::bucket/stack/my-stack", "arn:aws:cloudformation:eu-west-1:123456789012:stack/my-stack"} {
+		if _, err := ParseStackID(id); err == nil {
+			t.Errorf("Expected error for StackId %s", id)
+		}
+	}
+}
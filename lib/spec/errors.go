@@ -0,0 +1,36 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PropertyError is a validation error of a single property. Path is the path of the
+// property, such as RoleMappings.0.Type, so that the line of it in a template can be found.
+type PropertyError struct {
+	Path    string
+	Message string
+}
+
+// Error returns the message of the error.
+// Returns string.
+func (e *PropertyError) Error() string {
+	return e.Message
+}
+
+// Errorf takes the path of a property and returns a PropertyError with a formatted message.
+// Returns error.
+func Errorf(path string, format string, a ...interface{}) error {
+	return &PropertyError{Path: path, Message: fmt.Sprintf(format, a...)}
+}
+
+// ErrorPath returns the path of the property that err is about. An empty
+// string is returned if err isn't about a single property.
+// Returns string.
+func ErrorPath(err error) string {
+	var e *PropertyError
+	if errors.As(err, &e) {
+		return e.Path
+	}
+	return ""
+}
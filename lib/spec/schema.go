@@ -0,0 +1,185 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+)
+
+// schemaVersion is the JSON Schema draft that the CloudFormation resource schemas are based on.
+const schemaVersion = "https://json-schema.org/draft-07/schema#"
+
+// Schema is a JSON Schema of a custom resource in the style of the CloudFormation
+// registry resource schemas. It describes the Properties of the resource in a template,
+// with the Fn::GetAtt attributes as read only properties.
+//
+// There is no primaryIdentifier since the identifier of a custom resource is its
+// physical ID, which isn't one of its properties.
+type Schema struct {
+	Schema               string                 `json:"$schema"`
+	TypeName             string                 `json:"typeName"`
+	Description          string                 `json:"description"`
+	Definitions          map[string]*SchemaType `json:"definitions,omitempty"`
+	Properties           map[string]*SchemaType `json:"properties"`
+	Required             []string               `json:"required"`
+	ReadOnlyProperties   []string               `json:"readOnlyProperties,omitempty"`
+	CreateOnlyProperties []string               `json:"createOnlyProperties,omitempty"`
+	AdditionalProperties bool                   `json:"additionalProperties"`
+}
+
+// SchemaType is the schema of a single property or definition.
+type SchemaType struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *SchemaType            `json:"items,omitempty"`
+	Properties           map[string]*SchemaType `json:"properties,omitempty"`
+	PatternProperties    map[string]*SchemaType `json:"patternProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// schemaTypes maps the property types to JSON Schema types.
+var schemaTypes = map[string]string{
+	TypeString:  "string",
+	TypeInteger: "integer",
+	TypeBoolean: "boolean",
+	TypeList:    "array",
+	TypeMap:     "object",
+	TypeObject:  "object",
+}
+
+// Schema returns the JSON Schema of the resource. The ServiceToken and the common
+// properties from awsconfig.Target are part of the schema of every resource.
+// Returns *Schema and error.
+func (r *Resource) Schema() (*Schema, error) {
+	obj, err := r.Object()
+	if err != nil {
+		return nil, err
+	}
+
+	common, err := Parse(reflect.TypeOf(awsconfig.Target{}))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		Schema:      schemaVersion,
+		TypeName:    r.Type,
+		Description: r.Description,
+		Definitions: map[string]*SchemaType{},
+		Properties: map[string]*SchemaType{
+			"ServiceToken": {Type: "string", Description: "The ARN of the lambda function for this Custom Resource"},
+		},
+		Required: []string{"ServiceToken"},
+	}
+
+	for _, prop := range append(obj.Properties, common.Properties...) {
+		if _, ok := s.Properties[prop.Name]; ok {
+			continue
+		}
+
+		if s.Properties[prop.Name], err = s.property(prop); err != nil {
+			return nil, fmt.Errorf("%s: %s", r.Type, err.Error())
+		}
+
+		if prop.Required {
+			s.Required = append(s.Required, prop.Name)
+		}
+		if prop.CreateOnly {
+			s.CreateOnlyProperties = append(s.CreateOnlyProperties, "/properties/"+prop.Name)
+		}
+	}
+
+	// Attributes that only echo a property back aren't read only. Attributes with a dot in the
+	// name, such as TokenValidityUnits.AccessToken, are only a name for Fn::GetAtt and not a
+	// property of the resource.
+	for _, attr := range r.Attributes {
+		if _, ok := s.Properties[attr.Name]; ok || strings.Contains(attr.Name, ".") {
+			continue
+		}
+
+		s.Properties[attr.Name] = &SchemaType{Type: "string", Description: attr.Description}
+		s.ReadOnlyProperties = append(s.ReadOnlyProperties, "/properties/"+attr.Name)
+	}
+
+	return s, nil
+}
+
+// JSON returns the schema as indented JSON.
+// Returns []byte and error.
+func (s *Schema) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't marshal schema of %s. Error %s", s.TypeName, err.Error())
+	}
+	return append(b, '\n'), nil
+}
+
+// property takes prop and returns its schema. Nested objects are added to the
+// definitions of s and referenced.
+// Returns *SchemaType and error.
+func (s *Schema) property(prop *Property) (*SchemaType, error) {
+	t := &SchemaType{Type: schemaTypes[prop.Type], Description: prop.Description}
+
+	item := &SchemaType{Type: schemaTypes[prop.ItemType], Enum: prop.Enum}
+	if prop.Object != nil {
+		ref, err := s.define(prop.Object)
+		if err != nil {
+			return nil, err
+		}
+		item = &SchemaType{Ref: ref}
+	}
+
+	switch prop.Type {
+	case TypeList:
+		t.Items = item
+
+	case TypeMap:
+		t.PatternProperties = map[string]*SchemaType{".*": item}
+		t.AdditionalProperties = new(bool)
+
+	case TypeObject:
+		t.Type, t.Ref = "", item.Ref
+
+	default:
+		t.Enum = prop.Enum
+	}
+
+	return t, nil
+}
+
+// define adds obj to the definitions of s.
+// Returns the reference to the definition and error.
+func (s *Schema) define(obj *Object) (string, error) {
+	def := &SchemaType{Type: "object", Properties: map[string]*SchemaType{}, AdditionalProperties: new(bool)}
+	for _, prop := range obj.Properties {
+		t, err := s.property(prop)
+		if err != nil {
+			return "", err
+		}
+
+		def.Properties[prop.Name] = t
+		if prop.Required {
+			def.Required = append(def.Required, prop.Name)
+		}
+	}
+
+	// Objects are named after their Go type, two different types with the same name can't be told apart.
+	if existing, ok := s.Definitions[obj.Name]; ok && !reflect.DeepEqual(existing, def) {
+		return "", fmt.Errorf("There are different objects named %s", obj.Name)
+	}
+	s.Definitions[obj.Name] = def
+
+	return "#/definitions/" + obj.Name, nil
+}
+
+// FileName returns the name of the schema file of the resource, such as custom-cognitouserpoolclient.json.
+// Returns string.
+func (r *Resource) FileName() string {
+	return strings.ToLower(strings.Replace(r.Type, "::", "-", -1)) + ".json"
+}
@@ -0,0 +1,287 @@
+// Package spec describes the custom resources in this repository. Every resource
+// exports a Spec, and the properties are read from the struct that the resource
+// unmarshals its ResourceProperties into. This is used to generate the deployment
+// templates, the README property tables and the JSON schemas, so that they can't
+// drift from the code.
+//
+// Properties are annotated with struct tags. The description is set with the doc tag
+// and the cfn tag contains a comma separated list of the following annotations.
+//
+//	required       The property must be set.
+//	createOnly     Changing the property replaces the resource.
+//	type=Integer   The type of the property, for strings that contain numbers or booleans
+//	               since CloudFormation sends all values as strings.
+//	enum=A|B       The property must have one of the values separated by |.
+//
+// Example.
+//
+//	type Domain struct {
+//		Domain string `json:"Domain" cfn:"required,createOnly" doc:"Domain name to use"`
+//	}
+package spec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Property types, named as in CloudFormation's own documentation.
+const (
+	TypeString  = "String"
+	TypeInteger = "Integer"
+	TypeBoolean = "Boolean"
+	TypeList    = "List"
+	TypeMap     = "Map"
+	TypeObject  = "Object"
+)
+
+// Resource describes a custom resource.
+type Resource struct {
+	Type        string      // The ResourceType used in templates, such as Custom::CognitoUserPoolClient.
+	Description string      // Short description, used as Description of the deployment template.
+	Properties  interface{} // The struct that ResourceProperties are unmarshaled into.
+	Attributes  []Attribute // The attributes that can be used with Fn::GetAtt.
+	Policy      []Statement // The IAM policy that the lambda function needs.
+	Timeout     int         // The timeout of the lambda function in seconds, 60 if not set.
+
+	// Docs contains descriptions keyed by Object.Property for properties of
+	// types that can't have a doc tag, such as types from the AWS SDK.
+	Docs map[string]string
+}
+
+// Attribute is a value that can be used with Fn::GetAtt.
+type Attribute struct {
+	Name        string
+	Description string
+}
+
+// Statement is an IAM policy statement for the lambda function.
+type Statement struct {
+	Comment   string   // Written as a comment above the statement in the template.
+	Actions   []string // Actions such as cognito-idp:CreateUserPoolClient.
+	Resources []string // Resource ARNs, may contain ${} variables for Fn::Sub.
+
+	// Implicit is true if the actions are never called by the function itself,
+	// for example iam:PassRole. Otherwise every action must be called by the code.
+	Implicit bool
+}
+
+// Object is a struct of properties.
+type Object struct {
+	Name       string // The Go type name without the Type suffix.
+	Properties []*Property
+}
+
+// Property is a single property of an Object.
+type Property struct {
+	Name        string
+	Type        string  // One of the Type constants.
+	ItemType    string  // The type of the items of a List or Map.
+	Object      *Object // Set if Type or ItemType is Object.
+	Description string
+	Required    bool
+	CreateOnly  bool
+	Enum        []string
+}
+
+// Object returns the properties of the resource.
+// Returns *Object and error.
+func (r *Resource) Object() (*Object, error) {
+	if r.Properties == nil {
+		return nil, fmt.Errorf("%s has no Properties", r.Type)
+	}
+
+	obj, err := Parse(reflect.TypeOf(r.Properties))
+	if err != nil {
+		return nil, err
+	}
+
+	obj.Walk(func(o *Object, prop *Property) {
+		if prop.Description == "" {
+			prop.Description = r.Docs[o.Name+"."+prop.Name]
+		}
+	})
+	return obj, nil
+}
+
+// Walk calls fn for every property of obj and its nested objects.
+func (obj *Object) Walk(fn func(o *Object, prop *Property)) {
+	for _, prop := range obj.Properties {
+		fn(obj, prop)
+		if prop.Object != nil {
+			prop.Object.Walk(fn)
+		}
+	}
+}
+
+// Actions returns all actions in the policy of the resource.
+// Returns []string.
+func (r *Resource) Actions() []string {
+	l := []string{}
+	for _, s := range r.Policy {
+		l = append(l, s.Actions...)
+	}
+	return l
+}
+
+// Parse takes the struct type t and returns its exported properties.
+// Fields without a JSON name (json:"-") are not part of the properties.
+// Returns *Object and error.
+func Parse(t reflect.Type) (*Object, error) {
+	return parse(t, map[reflect.Type]bool{})
+}
+
+// parse parses t, seen contains the types currently being parsed to stop recursive types.
+// Returns *Object and error.
+func parse(t reflect.Type, seen map[reflect.Type]bool) (*Object, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() != reflect.Struct:
+		return nil, fmt.Errorf("%s is not a struct", t)
+
+	case seen[t]:
+		return nil, fmt.Errorf("%s is recursive", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	obj := &Object{Name: strings.TrimSuffix(t.Name(), "Type")}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := parseProperty(name, field, seen)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", obj.Name, name, err.Error())
+		}
+		obj.Properties = append(obj.Properties, prop)
+	}
+
+	return obj, nil
+}
+
+// parseProperty takes name and field and returns the Property of the field.
+// Returns *Property and error.
+func parseProperty(name string, field reflect.StructField, seen map[reflect.Type]bool) (*Property, error) {
+	// Types from the AWS SDK mark their required fields with required:"true".
+	prop := &Property{Name: name, Description: field.Tag.Get("doc"), Required: field.Tag.Get("required") == "true"}
+
+	typ, item, obj, err := kind(field.Type, seen)
+	if err != nil {
+		return nil, err
+	}
+	prop.Type, prop.ItemType, prop.Object = typ, item, obj
+
+	for _, annotation := range strings.Split(field.Tag.Get("cfn"), ",") {
+		key, value := annotation, ""
+		if i := strings.Index(annotation, "="); i >= 0 {
+			key, value = annotation[:i], annotation[i+1:]
+		}
+
+		switch key {
+		case "":
+
+		case "required":
+			prop.Required = true
+
+		case "createOnly":
+			prop.CreateOnly = true
+
+		case "enum":
+			prop.Enum = strings.Split(value, "|")
+
+		case "type":
+			if err := prop.setType(value); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("Unknown annotation %s", key)
+		}
+	}
+
+	return prop, nil
+}
+
+// setType overrides the scalar type of prop, or the item type of a List or Map.
+// Returns error.
+func (prop *Property) setType(typ string) error {
+	switch typ {
+	case TypeString, TypeInteger, TypeBoolean:
+
+	default:
+		return fmt.Errorf("Type %s can't be set on a property", typ)
+	}
+
+	switch {
+	case prop.Type == TypeList || prop.Type == TypeMap:
+		prop.ItemType = typ
+
+	case prop.Type == TypeObject:
+		return fmt.Errorf("Type can't be set on an Object")
+
+	default:
+		prop.Type = typ
+	}
+	return nil
+}
+
+// kind takes the Go type t and returns the property type, item type and object of it.
+// Returns string, string, *Object and error.
+func kind(t reflect.Type, seen map[reflect.Type]bool) (string, string, *Object, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return TypeString, "", nil, nil
+
+	case reflect.Bool:
+		return TypeBoolean, "", nil, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInteger, "", nil, nil
+
+	case reflect.Struct:
+		obj, err := parse(t, seen)
+		return TypeObject, "", obj, err
+
+	case reflect.Slice, reflect.Array:
+		// []byte is unmarshaled from a base64 encoded string.
+		if t.Elem().Kind() == reflect.Uint8 {
+			return TypeString, "", nil, nil
+		}
+
+		item, _, obj, err := kind(t.Elem(), seen)
+		if item == TypeList || item == TypeMap {
+			return "", "", nil, fmt.Errorf("Nested lists and maps aren't supported")
+		}
+		return TypeList, item, obj, err
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", "", nil, fmt.Errorf("Map keys must be strings")
+		}
+
+		item, _, obj, err := kind(t.Elem(), seen)
+		if item == TypeList || item == TypeMap {
+			return "", "", nil, fmt.Errorf("Nested lists and maps aren't supported")
+		}
+		return TypeMap, item, obj, err
+	}
+
+	return "", "", nil, fmt.Errorf("Type %s isn't supported", t)
+}
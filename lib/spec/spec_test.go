@@ -0,0 +1,197 @@
+package spec
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testProperties struct {
+	Name     string            `json:"Name" cfn:"required,createOnly" doc:"Name of it"`
+	Count    string            `json:"Count" cfn:"type=Integer"`
+	Mode     string            `json:"Mode" cfn:"enum=ON|OFF"`
+	Flags    []string          `json:"Flags" cfn:"type=Boolean"`
+	Labels   map[string]string `json:"Labels"`
+	Items    []testItemType    `json:"Items"`
+	Secret   []byte            `json:"Secret"`
+	Internal string            `json:"-"`
+	private  string
+}
+
+type testItemType struct {
+	Key   *string `json:"Key" required:"true"`
+	Value *string `json:"Value"`
+}
+
+// Test that properties are parsed from the struct and its tags.
+func TestParse(t *testing.T) {
+	obj, err := Parse(reflect.TypeOf(testProperties{}))
+	if err != nil {
+		t.Fatalf("Couldn't parse. Error %s", err.Error())
+	}
+
+	item := &Object{Name: "testItem", Properties: []*Property{
+		{Name: "Key", Type: TypeString, Required: true},
+		{Name: "Value", Type: TypeString},
+	}}
+
+	tests := []*Property{
+		{Name: "Name", Type: TypeString, Description: "Name of it", Required: true, CreateOnly: true},
+		{Name: "Count", Type: TypeInteger},
+		{Name: "Mode", Type: TypeString, Enum: []string{"ON", "OFF"}},
+		{Name: "Flags", Type: TypeList, ItemType: TypeBoolean},
+		{Name: "Labels", Type: TypeMap, ItemType: TypeString},
+		{Name: "Items", Type: TypeList, ItemType: TypeObject, Object: item},
+		{Name: "Secret", Type: TypeString},
+	}
+
+	if len(obj.Properties) != len(tests) {
+		t.Fatalf("Expected %d properties but got %d", len(tests), len(obj.Properties))
+	}
+
+	for i, test := range tests {
+		if !reflect.DeepEqual(obj.Properties[i], test) {
+			t.Errorf("Test number: %d failed. Wanted %+v but got %+v", i+1, test, obj.Properties[i])
+		}
+	}
+}
+
+// Test that types and annotations that can't be described give an error.
+func TestParseError(t *testing.T) {
+	tests := []interface{}{
+		"not a struct",
+		struct {
+			A string `cfn:"unknown"`
+		}{},
+		struct {
+			A string `cfn:"type=Float"`
+		}{},
+		struct {
+			A testItemType `cfn:"type=String"`
+		}{},
+		struct {
+			A [][]string
+		}{},
+		struct {
+			A map[int]string
+		}{},
+		struct {
+			A float64
+		}{},
+	}
+
+	for i, test := range tests {
+		if _, err := Parse(reflect.TypeOf(test)); err == nil {
+			t.Errorf("Test number: %d failed. Expected an error", i+1)
+		}
+	}
+}
+
+// Test that descriptions are taken from Docs when there is no doc tag.
+func TestResourceObject(t *testing.T) {
+	r := &Resource{
+		Type:       "Custom::Test",
+		Properties: testProperties{},
+		Docs:       map[string]string{"testItem.Key": "Name of item", "testProperties.Name": "Not used"},
+		Policy: []Statement{
+			{Actions: []string{"iam:TagRole", "iam:UntagRole"}},
+			{Actions: []string{"iam:PassRole"}, Implicit: true},
+		},
+	}
+
+	obj, err := r.Object()
+	if err != nil {
+		t.Fatalf("Couldn't get object. Error %s", err.Error())
+	}
+
+	descriptions := map[string]string{}
+	obj.Walk(func(o *Object, prop *Property) {
+		descriptions[o.Name+"."+prop.Name] = prop.Description
+	})
+
+	switch {
+	case descriptions["testItem.Key"] != "Name of item":
+		t.Errorf("Expected description from Docs but got %q", descriptions["testItem.Key"])
+
+	case descriptions["testProperties.Name"] != "Name of it":
+		t.Errorf("Expected description from doc tag but got %q", descriptions["testProperties.Name"])
+
+	case !reflect.DeepEqual(r.Actions(), []string{"iam:TagRole", "iam:UntagRole", "iam:PassRole"}):
+		t.Errorf("Unexpected actions %v", r.Actions())
+	}
+
+	if _, err := (&Resource{Type: "Custom::Empty"}).Object(); err == nil {
+		t.Errorf("Expected an error for a resource without Properties")
+	}
+}
+
+// Test that the schema contains the properties, attributes and common properties.
+func TestSchema(t *testing.T) {
+	r := &Resource{
+		Type:        "Custom::Test",
+		Description: "Test resource",
+		Properties:  testProperties{},
+		Attributes: []Attribute{
+			{Name: "Name", Description: "Echo of the property"},
+			{Name: "Id", Description: "The ID"},
+			{Name: "Items.Key", Description: "Key of the item"},
+		},
+	}
+
+	s, err := r.Schema()
+	if err != nil {
+		t.Fatalf("Couldn't get schema. Error %s", err.Error())
+	}
+
+	tests := []struct {
+		got  interface{}
+		want interface{}
+	}{
+		{got: s.TypeName, want: "Custom::Test"},
+		{got: s.Required, want: []string{"ServiceToken", "Name"}},
+		{got: s.ReadOnlyProperties, want: []string{"/properties/Id"}},
+		{got: s.CreateOnlyProperties, want: []string{"/properties/Name", "/properties/RoleArn", "/properties/ExternalId", "/properties/Region"}},
+		{got: s.Properties["Count"].Type, want: "integer"},
+		{got: s.Properties["Mode"].Enum, want: []string{"ON", "OFF"}},
+		{got: s.Properties["Flags"].Items.Type, want: "boolean"},
+		{got: s.Properties["Labels"].PatternProperties[".*"].Type, want: "string"},
+		{got: s.Properties["Items"].Items.Ref, want: "#/definitions/testItem"},
+		{got: s.Definitions["testItem"].Required, want: []string{"Key"}},
+		{got: s.Properties["Region"].Type, want: "string"},
+		{got: s.Properties["Items.Key"], want: (*SchemaType)(nil)},
+		{got: r.FileName(), want: "custom-test.json"},
+	}
+
+	for i, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("Test number: %d failed. Wanted %v but got %v", i+1, test.want, test.got)
+		}
+	}
+
+	if _, err := s.JSON(); err != nil {
+		t.Errorf("Couldn't marshal schema. Error %s", err.Error())
+	}
+}
+
+// Test that the path of a property error is found, also when wrapped.
+func TestErrorPath(t *testing.T) {
+	tests := []struct {
+		err  error
+		path string
+	}{
+		{err: nil},
+		{err: fmt.Errorf("Failed to set MFA")},
+		{err: Errorf("UserPoolId", "No UserPoolId specified"), path: "UserPoolId"},
+		{err: fmt.Errorf("Invalid properties. %w", Errorf("RoleMappings.0.Type", "Type is not valid")), path: "RoleMappings.0.Type"},
+	}
+
+	for i, test := range tests {
+		if path := ErrorPath(test.err); path != test.path {
+			t.Errorf("Test number: %d failed. Wanted path %q but got %q", i+1, test.path, path)
+		}
+	}
+
+	if err := Errorf("Tags.0.Key", "No %s set in Tags", "Key"); err.Error() != "No Key set in Tags" {
+		t.Errorf("Unexpected message %s", err.Error())
+	}
+}
@@ -0,0 +1,535 @@
+// Package template parses CloudFormation templates written in YAML or JSON
+// and resolves the intrinsic functions that can be resolved outside of
+// CloudFormation. Short-form intrinsics such as !Ref and !Sub are converted
+// to their long form so that the rest of the code only has one form to handle.
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NoValue is the pseudo parameter that removes a property when referenced.
+const NoValue = "AWS::NoValue"
+
+// Template is a parsed CloudFormation template.
+type Template struct {
+	Parameters map[string]*Parameter
+	Resources  []*Resource // In the order they appear in the template.
+}
+
+// Parameter is a parameter declared in the template.
+type Parameter struct {
+	Name    string
+	Default string
+	Line    int
+}
+
+// Resource is a resource declared in the template.
+// Properties contain the raw values from the template with all intrinsic
+// functions in long form, such as map[string]interface{}{"Ref": "UserPool"}.
+type Resource struct {
+	LogicalID  string
+	Type       string
+	Line       int
+	Properties map[string]interface{}
+
+	lines map[string]int // Line numbers keyed by property path.
+}
+
+// Parse takes b which is a template in YAML or JSON and parses it.
+// Returns *Template and error.
+func Parse(b []byte) (*Template, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("Couldn't parse template. Error %s", err.Error())
+	}
+
+	// An empty document will not contain any content.
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("Template is empty")
+	}
+
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Template on line %d is not a map", root.Line)
+	}
+
+	t := &Template{Parameters: map[string]*Parameter{}, Resources: []*Resource{}}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], resolveAlias(root.Content[i+1])
+
+		switch key.Value {
+		case "Parameters":
+			if err := t.parseParameters(value); err != nil {
+				return nil, err
+			}
+
+		case "Resources":
+			if err := t.parseResources(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// parseParameters takes node and adds all the parameters and their default values to t.
+// Returns error.
+func (t *Template) parseParameters(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("Parameters on line %d is not a map", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		param := &Parameter{Name: key.Value, Line: key.Line}
+
+		if value.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if value.Content[j].Value == "Default" {
+					param.Default = resolveAlias(value.Content[j+1]).Value
+				}
+			}
+		}
+
+		t.Parameters[param.Name] = param
+	}
+
+	return nil
+}
+
+// parseResources takes node and adds all the resources to t.
+// Returns error.
+func (t *Template) parseResources(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("Resources on line %d is not a map", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		if value.Kind != yaml.MappingNode {
+			return fmt.Errorf("Resource %s on line %d is not a map", key.Value, key.Line)
+		}
+
+		res := &Resource{
+			LogicalID:  key.Value,
+			Line:       key.Line,
+			Properties: map[string]interface{}{},
+			lines:      map[string]int{},
+		}
+
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			field, fieldValue := value.Content[j], resolveAlias(value.Content[j+1])
+
+			switch field.Value {
+			case "Type":
+				res.Type = fieldValue.Value
+
+			case "Properties":
+				props, ok := convert(fieldValue, "", res.lines).(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("Properties of resource %s on line %d is not a map", res.LogicalID, field.Line)
+				}
+				res.Properties = props
+			}
+		}
+
+		t.Resources = append(t.Resources, res)
+	}
+
+	return nil
+}
+
+// Pos returns the line number of the property at path, such as
+// "AnalyticsConfiguration.RoleArn" or "CallbackURLs.0". If the exact path
+// can't be found the closest parent is used, and lastly the line of the resource.
+// Returns int.
+func (r *Resource) Pos(path string) int {
+	for path != "" {
+		if line, ok := r.lines[path]; ok {
+			return line
+		}
+
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+
+	return r.Line
+}
+
+// resolveAlias will return the node that an alias points to.
+// Returns *yaml.Node.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// convert takes node and converts it to plain go values. Short-form intrinsic functions
+// will be converted to their long form. The line number of every value is saved in lines
+// by its path.
+// Returns interface{}.
+func convert(node *yaml.Node, path string, lines map[string]int) interface{} {
+	node = resolveAlias(node)
+	if path != "" {
+		lines[path] = node.Line
+	}
+
+	var val interface{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			child := join(path, key.Value)
+			m[key.Value] = convert(node.Content[i+1], child, lines)
+			lines[child] = key.Line
+		}
+		val = m
+
+	case yaml.SequenceNode:
+		l := make([]interface{}, 0, len(node.Content))
+		for i, item := range node.Content {
+			l = append(l, convert(item, join(path, strconv.Itoa(i)), lines))
+		}
+		val = l
+
+	default:
+		if node.Tag == "!!null" {
+			return nil
+		}
+		val = node.Value
+	}
+
+	return shortForm(node, val)
+}
+
+// shortForm takes node and its converted value val and returns the long form of
+// the intrinsic function if the node was tagged with one. Otherwise val is returned.
+// Returns interface{}.
+func shortForm(node *yaml.Node, val interface{}) interface{} {
+	if !strings.HasPrefix(node.Tag, "!") || strings.HasPrefix(node.Tag, "!!") {
+		return val
+	}
+
+	name := strings.TrimPrefix(node.Tag, "!")
+	switch name {
+	case "Ref", "Condition":
+		return map[string]interface{}{name: val}
+
+	case "GetAtt":
+		// !GetAtt Resource.Attribute is short for [Resource, Attribute].
+		if s, ok := val.(string); ok {
+			parts := strings.SplitN(s, ".", 2)
+			l := []interface{}{}
+			for _, part := range parts {
+				l = append(l, part)
+			}
+			val = l
+		}
+	}
+
+	return map[string]interface{}{"Fn::" + name: val}
+}
+
+// join returns path and key joined with a dot.
+// Returns string.
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// IsIntrinsic returns true if v is a long-form intrinsic function.
+// Returns bool.
+func IsIntrinsic(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return false
+	}
+
+	for key := range m {
+		return key == "Ref" || key == "Condition" || strings.HasPrefix(key, "Fn::")
+	}
+	return false
+}
+
+// Resolve takes refs and resolves the properties of r in the same way as CloudFormation
+// would send them to a custom resource, with all scalar values as strings.
+// refs should contain the values for Ref, such as parameters, pseudo parameters
+// and physical IDs keyed by logical ID. Values for Fn::GetAtt can be supplied keyed
+// by "LogicalId.Attribute".
+// Properties that contain intrinsic functions that can't be resolved are left out and
+// their paths are returned as skipped.
+// Returns json.RawMessage, []string and error.
+func (r *Resource) Resolve(refs map[string]string) (json.RawMessage, []string, error) {
+	skipped := []string{}
+	props := map[string]interface{}{}
+
+	for _, key := range sortedKeys(r.Properties) {
+		val, ok := resolve(r.Properties[key], key, refs, &skipped)
+		switch {
+		case !ok:
+			skipped = append(skipped, key)
+
+		case val != nil:
+			props[key] = val
+		}
+	}
+
+	b, err := json.Marshal(props)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Couldn't marshal properties of %s. Error %s", r.LogicalID, err.Error())
+	}
+
+	sort.Strings(skipped)
+	return b, skipped, nil
+}
+
+// resolve takes v and resolves all intrinsic functions in it by using refs.
+// Unresolved map keys further down are appended to skipped. The returned bool is
+// false if v itself couldn't be resolved.
+// Returns interface{} and bool.
+func resolve(v interface{}, path string, refs map[string]string, skipped *[]string) (interface{}, bool) {
+	if IsIntrinsic(v) {
+		s, ok := resolveIntrinsic(v.(map[string]interface{}), refs)
+		if !ok {
+			return nil, false
+		}
+		if s == NoValue {
+			return nil, true
+		}
+		return s, true
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for _, key := range sortedKeys(val) {
+			child, ok := resolve(val[key], join(path, key), refs, skipped)
+			switch {
+			case !ok:
+				*skipped = append(*skipped, join(path, key))
+
+			case child != nil:
+				m[key] = child
+			}
+		}
+		return m, true
+
+	case []interface{}:
+		// A partially resolved list would not describe what is declared.
+		// So the whole list is left out if any item can't be resolved.
+		l := []interface{}{}
+		for i, item := range val {
+			child, ok := resolve(item, join(path, strconv.Itoa(i)), refs, skipped)
+			if !ok {
+				return nil, false
+			}
+			if child != nil {
+				l = append(l, child)
+			}
+		}
+		return l, true
+	}
+
+	return v, true
+}
+
+// resolveIntrinsic takes the long-form intrinsic function fn and tries to resolve
+// it to a string by using refs. Fn::Sub, Fn::Join, Fn::Select, Fn::GetAtt and Ref are
+// supported. If the function references AWS::NoValue NoValue is returned.
+// Returns string and bool.
+func resolveIntrinsic(fn map[string]interface{}, refs map[string]string) (string, bool) {
+	for name, arg := range fn {
+		switch name {
+		case "Ref":
+			s, ok := arg.(string)
+			if !ok {
+				return "", false
+			}
+			if s == NoValue {
+				return NoValue, true
+			}
+			val, ok := refs[s]
+			return val, ok
+
+		case "Fn::GetAtt":
+			l, ok := arg.([]interface{})
+			if !ok || len(l) != 2 {
+				return "", false
+			}
+			val, ok := refs[fmt.Sprintf("%v.%v", l[0], l[1])]
+			return val, ok
+
+		case "Fn::Sub":
+			return resolveSub(arg, refs)
+
+		case "Fn::Join":
+			l, ok := arg.([]interface{})
+			if !ok || len(l) != 2 {
+				return "", false
+			}
+			sep, ok := l[0].(string)
+			if !ok {
+				return "", false
+			}
+			items, ok := resolveList(l[1], refs)
+			if !ok {
+				return "", false
+			}
+			return strings.Join(items, sep), true
+
+		case "Fn::Select":
+			l, ok := arg.([]interface{})
+			if !ok || len(l) != 2 {
+				return "", false
+			}
+			index, ok := resolveString(l[0], refs)
+			if !ok {
+				return "", false
+			}
+			n, err := strconv.Atoi(index)
+			if err != nil {
+				return "", false
+			}
+			items, ok := resolveList(l[1], refs)
+			if !ok || n < 0 || n >= len(items) {
+				return "", false
+			}
+			return items[n], true
+		}
+	}
+
+	return "", false
+}
+
+// resolveSub takes the argument of Fn::Sub and substitutes all variables in it.
+// Returns string and bool.
+func resolveSub(arg interface{}, refs map[string]string) (string, bool) {
+	str := ""
+	vars := map[string]string{}
+
+	switch val := arg.(type) {
+	case string:
+		str = val
+
+	case []interface{}:
+		if len(val) != 2 {
+			return "", false
+		}
+		s, ok := val[0].(string)
+		if !ok {
+			return "", false
+		}
+		str = s
+
+		m, ok := val[1].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		for key, v := range m {
+			s, ok := resolveString(v, refs)
+			if !ok {
+				return "", false
+			}
+			vars[key] = s
+		}
+
+	default:
+		return "", false
+	}
+
+	out := strings.Builder{}
+	for {
+		start := strings.Index(str, "${")
+		if start < 0 {
+			out.WriteString(str)
+			break
+		}
+		end := strings.Index(str[start:], "}")
+		if end < 0 {
+			out.WriteString(str)
+			break
+		}
+		end += start
+
+		out.WriteString(str[:start])
+		name := str[start+2 : end]
+
+		switch {
+		// ${!Literal} is written as ${Literal}.
+		case strings.HasPrefix(name, "!"):
+			out.WriteString("${" + name[1:] + "}")
+
+		default:
+			val, ok := vars[name]
+			if !ok {
+				val, ok = refs[name]
+			}
+			if !ok {
+				return "", false
+			}
+			out.WriteString(val)
+		}
+
+		str = str[end+1:]
+	}
+
+	return out.String(), true
+}
+
+// resolveString takes v and resolves it to a string.
+// Returns string and bool.
+func resolveString(v interface{}, refs map[string]string) (string, bool) {
+	if IsIntrinsic(v) {
+		return resolveIntrinsic(v.(map[string]interface{}), refs)
+	}
+
+	s, ok := v.(string)
+	return s, ok
+}
+
+// resolveList takes v and resolves it to a list of strings.
+// Returns []string and bool.
+func resolveList(v interface{}, refs map[string]string) ([]string, bool) {
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	items := []string{}
+	for _, item := range l {
+		s, ok := resolveString(item, refs)
+		if !ok {
+			return nil, false
+		}
+		items = append(items, s)
+	}
+
+	return items, true
+}
+
+// sortedKeys returns the keys of m in sorted order.
+// Returns []string.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+package template
+
+import (
+	"testing"
+)
+
+const testTemplate = `AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Environment:
+    Type: "String"
+    Default: "dev"
+
+Resources:
+  UserPool:
+    Type: "AWS::Cognito::UserPool"
+    Properties:
+      UserPoolName: "userpool"
+
+  UserPoolClient:
+    Type: "Custom::CognitoUserPoolClient"
+    Properties:
+      ClientName: !Sub "client-${Environment}"
+      GenerateSecret: true
+      RefreshTokenValidity: 30
+      UserPoolId: !Ref "UserPool"
+      CallbackURLs:
+        - !Join ["", ["https://", !GetAtt "Distribution.DomainName", "/"]]
+      AnalyticsConfiguration:
+        ApplicationId: !GetAtt Pinpoint.ApplicationId
+        RoleArn: "arn:aws:iam::123456789012:role/analytics"
+      DefaultRedirectURI: !Ref "AWS::NoValue"
+      ServiceToken: !Sub "arn:aws:lambda:${AWS::Region}:${AWS::AccountId}:function:cognito-userpool-client"
+`
+
+// Test that parsing the template finds all resources, parameters and lines.
+func TestParse(t *testing.T) {
+	tmpl, err := Parse([]byte(testTemplate))
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	if tmpl.Parameters["Environment"] == nil || tmpl.Parameters["Environment"].Default != "dev" {
+		t.Errorf("Expected parameter Environment with default dev but got %+v", tmpl.Parameters["Environment"])
+	}
+
+	if len(tmpl.Resources) != 2 {
+		t.Fatalf("Expected 2 resources but got %d", len(tmpl.Resources))
+	}
+
+	res := tmpl.Resources[1]
+	if res.LogicalID != "UserPoolClient" || res.Type != "Custom::CognitoUserPoolClient" || res.Line != 13 {
+		t.Errorf("Expected UserPoolClient of type Custom::CognitoUserPoolClient on line 13 but got %s %s %d", res.LogicalID, res.Type, res.Line)
+	}
+
+	tests := map[string]int{
+		"ClientName":                     16,
+		"CallbackURLs.0":                 21,
+		"AnalyticsConfiguration.RoleArn": 24,
+		"AnalyticsConfiguration.Missing": 22,
+		"Missing":                        13,
+	}
+	for path, line := range tests {
+		if res.Pos(path) != line {
+			t.Errorf("Expected %s on line %d but got %d", path, line, res.Pos(path))
+		}
+	}
+
+	ref, ok := res.Properties["UserPoolId"].(map[string]interface{})
+	if !ok || ref["Ref"] != "UserPool" {
+		t.Errorf("Expected UserPoolId to be converted to long-form Ref but got %+v", res.Properties["UserPoolId"])
+	}
+
+	getAtt, ok := res.Properties["AnalyticsConfiguration"].(map[string]interface{})["ApplicationId"].(map[string]interface{})
+	if !ok || len(getAtt["Fn::GetAtt"].([]interface{})) != 2 {
+		t.Errorf("Expected ApplicationId to be converted to long-form Fn::GetAtt but got %+v", getAtt)
+	}
+}
+
+// Test that parsing JSON templates works.
+func TestParseJSON(t *testing.T) {
+	tmpl, err := Parse([]byte(`{"Resources":{"Mfa":{"Type":"Custom::CognitoUserPoolMFA","Properties":{"UserPoolId":{"Ref":"UserPool"}}}}}`))
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	if len(tmpl.Resources) != 1 || tmpl.Resources[0].Type != "Custom::CognitoUserPoolMFA" {
+		t.Fatalf("Expected 1 resource of type Custom::CognitoUserPoolMFA but got %+v", tmpl.Resources)
+	}
+
+	if !IsIntrinsic(tmpl.Resources[0].Properties["UserPoolId"]) {
+		t.Errorf("Expected UserPoolId to be an intrinsic function")
+	}
+}
+
+// Test that templates that aren't maps fail.
+func TestParseInvalid(t *testing.T) {
+	for _, body := range []string{"", "- list", "Resources: string", "Resources:\n  Res: string"} {
+		if _, err := Parse([]byte(body)); err == nil {
+			t.Errorf("Expected error for %q but got nil", body)
+		}
+	}
+}
+
+// Test that Resolve resolves the intrinsics it can and skips the rest.
+func TestResolve(t *testing.T) {
+	tmpl, err := Parse([]byte(testTemplate))
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	refs := map[string]string{
+		"Environment":    "prod",
+		"UserPool":       "eu-west-1_abc",
+		"AWS::Region":    "eu-west-1",
+		"AWS::AccountId": "123456789012",
+	}
+
+	props, skipped, err := tmpl.Resources[1].Resolve(refs)
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	expected := `{"AnalyticsConfiguration":{"RoleArn":"arn:aws:iam::123456789012:role/analytics"},"ClientName":"client-prod","GenerateSecret":"true","RefreshTokenValidity":"30","ServiceToken":"arn:aws:lambda:eu-west-1:123456789012:function:cognito-userpool-client","UserPoolId":"eu-west-1_abc"}`
+	if string(props) != expected {
+		t.Errorf("Expected %s but got %s", expected, string(props))
+	}
+
+	if len(skipped) != 2 || skipped[0] != "AnalyticsConfiguration.ApplicationId" || skipped[1] != "CallbackURLs" {
+		t.Errorf("Expected AnalyticsConfiguration.ApplicationId and CallbackURLs to be skipped but got %v", skipped)
+	}
+
+	// Supplying the GetAtt values will resolve the rest.
+	refs["Distribution.DomainName"] = "d111.cloudfront.net"
+	refs["Pinpoint.ApplicationId"] = "app"
+	props, skipped, err = tmpl.Resources[1].Resolve(refs)
+	if err != nil {
+		t.Fatalf("Got error %s", err.Error())
+	}
+
+	expected = `{"AnalyticsConfiguration":{"ApplicationId":"app","RoleArn":"arn:aws:iam::123456789012:role/analytics"},"CallbackURLs":["https://d111.cloudfront.net/"],"ClientName":"client-prod","GenerateSecret":"true","RefreshTokenValidity":"30","ServiceToken":"arn:aws:lambda:eu-west-1:123456789012:function:cognito-userpool-client","UserPoolId":"eu-west-1_abc"}`
+	if string(props) != expected || len(skipped) != 0 {
+		t.Errorf("Expected %s but got %s and skipped %v", expected, string(props), skipped)
+	}
+}
+
+// Test the different forms of Fn::Sub and Fn::Select.
+func TestResolveIntrinsic(t *testing.T) {
+	refs := map[string]string{"Name": "world"}
+
+	tests := []struct {
+		fn       map[string]interface{}
+		expected string
+		ok       bool
+	}{
+		{fn: map[string]interface{}{"Fn::Sub": "hello ${Name}"}, expected: "hello world", ok: true},
+		{fn: map[string]interface{}{"Fn::Sub": "hello ${!Name}"}, expected: "hello ${Name}", ok: true},
+		{fn: map[string]interface{}{"Fn::Sub": []interface{}{"${A}-${Name}", map[string]interface{}{"A": "a"}}}, expected: "a-world", ok: true},
+		{fn: map[string]interface{}{"Fn::Sub": "${Missing}"}, ok: false},
+		{fn: map[string]interface{}{"Fn::Select": []interface{}{"1", []interface{}{"a", "b"}}}, expected: "b", ok: true},
+		{fn: map[string]interface{}{"Fn::Select": []interface{}{"2", []interface{}{"a", "b"}}}, ok: false},
+		{fn: map[string]interface{}{"Fn::ImportValue": "export"}, ok: false},
+	}
+
+	for i, test := range tests {
+		val, ok := resolveIntrinsic(test.fn, refs)
+		if ok != test.ok || val != test.expected {
+			t.Errorf("Test number: %d failed. Wanted %q %t but got %q %t", i+1, test.expected, test.ok, val, ok)
+		}
+	}
+}
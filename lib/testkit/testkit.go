@@ -0,0 +1,422 @@
+// Package testkit is used to test the Handler of a custom resource without AWS or
+// CloudFormation. A Kit runs a local server that stands in for both the AWS APIs and
+// the pre-signed S3 url, so the whole Handler runs as it would in the lambda function.
+//
+// Responses of the AWS APIs are mocked by operation name, such as CreateUserPoolClient.
+// The JSON APIs (Cognito), the query APIs (IAM, STS) and the REST-XML API of Route 53
+// are supported.
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+const (
+	// Region is the region that the Handler runs in.
+	Region = "eu-west-1"
+
+	// AccountID is the account of the stack that the requests come from.
+	AccountID = "123456789012"
+
+	// StackID is the StackId of all requests.
+	StackID = "arn:aws:cloudformation:" + Region + ":" + AccountID + ":stack/testkit/5b6b3fe0-1f4e-11e9-a6c8-0a1528792fce"
+
+	// LogicalResourceID is the LogicalResourceId of all requests.
+	LogicalResourceID = "Resource"
+
+	// LambdaRequestID is the request ID of the lambda invocation that runs the Handler.
+	LambdaRequestID = "7c1f9d2e-testkit"
+
+	// LogGroup and LogStream are the CloudWatch logs of the lambda function.
+	LogGroup  = "/aws/lambda/testkit"
+	LogStream = "2019/01/22/[$LATEST]testkit"
+
+	// responsePath is the path of the ResponseURL on the local server.
+	responsePath = "/cloudformation-response"
+
+	// timeout is the time that the Handler has to finish, like the timeout of the lambda function.
+	timeout = 30 * time.Second
+)
+
+// The kinds of AWS APIs, which name operations and format responses differently.
+const (
+	apiJSON  = "json"  // The operation is named by the X-Amz-Target header.
+	apiQuery = "query" // The operation is named by the Action parameter.
+	apiREST  = "rest"  // The operation is named by the method and path, see restOperations.
+)
+
+// restOperation is an operation of a REST-XML API.
+type restOperation struct {
+	method string
+	path   *regexp.Regexp
+	name   string
+}
+
+// restOperations are the operations of the REST-XML APIs that the resources call.
+var restOperations = []restOperation{
+	{method: http.MethodPost, path: regexp.MustCompile(`^/2013-04-01/hostedzone/[^/]+/rrset/?$`), name: "ChangeResourceRecordSets"},
+	{method: http.MethodGet, path: regexp.MustCompile(`^/2013-04-01/hostedzone/[^/]+/rrset/?$`), name: "ListResourceRecordSets"},
+	{method: http.MethodGet, path: regexp.MustCompile(`^/2013-04-01/change/[^/]+$`), name: "GetChange"},
+}
+
+// environment is the environment that the Handler runs with.
+var environment = map[string]string{
+	"AWS_REGION":                  Region,
+	"AWS_ACCESS_KEY_ID":           "testkit",
+	"AWS_SECRET_ACCESS_KEY":       "testkit",
+	"AWS_SESSION_TOKEN":           "",
+	"AWS_PROFILE":                 "",
+	"AWS_CONFIG_FILE":             os.DevNull,
+	"AWS_SHARED_CREDENTIALS_FILE": os.DevNull,
+}
+
+// Kit contains the local server and everything that the Handler sent to it.
+type Kit struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	mocks     map[string]*mock
+	calls     []*Call
+	responses []*Response
+}
+
+// mock is the mocked response of an operation.
+type mock struct {
+	status int
+	body   string
+	next   *mock // The mock of the next call, nil if this mock answers every call.
+}
+
+// Call is a request to the AWS APIs made by the Handler.
+type Call struct {
+	Operation string     // Such as CreateUserPoolClient.
+	Body      []byte     // The JSON input of JSON APIs and the XML input of REST-XML APIs.
+	Params    url.Values // The form input of query APIs and the query string of REST-XML APIs.
+}
+
+// Response is the response that the Handler sent to CloudFormation.
+type Response struct {
+	Status             string            `json:"Status"`
+	Reason             string            `json:"Reason"`
+	PhysicalResourceID string            `json:"PhysicalResourceId"`
+	StackID            string            `json:"StackId"`
+	RequestID          string            `json:"RequestId"`
+	LogicalResourceID  string            `json:"LogicalResourceId"`
+	Data               map[string]string `json:"Data"`
+}
+
+// New takes t and starts a Kit. Every AWS config loaded with lib/awsconfig, and so every
+// client created with lib/awsclient, will send its requests to the Kit until the test is finished.
+// Returns *Kit.
+func New(t *testing.T) *Kit {
+	k := &Kit{t: t, mocks: map[string]*mock{}}
+	k.srv = httptest.NewServer(http.HandlerFunc(k.serve))
+
+	old := map[string]*string{}
+	for key, val := range environment {
+		if current, ok := os.LookupEnv(key); ok {
+			old[key] = &current
+		} else {
+			old[key] = nil
+		}
+		os.Setenv(key, val)
+	}
+
+	oldEndpoints, oldAllowed := awsconfig.Endpoints, events.AllowedResponseURLs
+	awsconfig.Endpoints = map[string]string{"*": k.srv.URL}
+	events.AllowedResponseURLs = []string{k.srv.URL + responsePath}
+
+	oldGroup, oldStream := lambdacontext.LogGroupName, lambdacontext.LogStreamName
+	lambdacontext.LogGroupName, lambdacontext.LogStreamName = LogGroup, LogStream
+
+	t.Cleanup(func() {
+		k.srv.Close()
+		awsconfig.Endpoints, events.AllowedResponseURLs = oldEndpoints, oldAllowed
+		lambdacontext.LogGroupName, lambdacontext.LogStreamName = oldGroup, oldStream
+
+		for key, val := range old {
+			if val == nil {
+				os.Unsetenv(key)
+				continue
+			}
+			os.Setenv(key, *val)
+		}
+	})
+
+	return k
+}
+
+// Respond mocks the response of operation with body. For JSON APIs body is the JSON
+// output, for query APIs it's the XML of the result without the surrounding Response
+// and Result elements, and for REST-XML APIs the XML without the Response element.
+// An empty body is an empty output.
+func (k *Kit) Respond(operation string, body string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.mocks[operation] = &mock{status: http.StatusOK, body: body}
+}
+
+// RespondInOrder mocks the responses of operation with bodies, one body per call in order,
+// such as the pages of a list. The last body answers every call after that.
+func (k *Kit) RespondInOrder(operation string, bodies ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var m *mock
+	for i := len(bodies) - 1; i >= 0; i-- {
+		m = &mock{status: http.StatusOK, body: bodies[i], next: m}
+	}
+	k.mocks[operation] = m
+}
+
+// Fail mocks operation to fail with the AWS error code and message, such as
+// ResourceNotFoundException.
+func (k *Kit) Fail(operation string, code string, message string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.mocks[operation] = &mock{status: http.StatusBadRequest, body: code + ":" + message}
+}
+
+// Request takes requestType, resourceType and the properties and returns a valid
+// request from CloudFormation. properties and oldProperties are marshaled to JSON,
+// strings and []byte are used as is. oldProperties are only used on Update.
+// Returns *events.Request.
+func (k *Kit) Request(requestType string, resourceType string, properties interface{}, oldProperties interface{}) *events.Request {
+	req := &events.Request{
+		RequestType:        requestType,
+		ResponseURL:        k.srv.URL + responsePath + "?X-Amz-Signature=testkit",
+		StackID:            StackID,
+		RequestID:          fmt.Sprintf("testkit-%d", time.Now().UnixNano()),
+		ResourceType:       resourceType,
+		LogicalResourceID:  LogicalResourceID,
+		ResourceProperties: k.marshal(properties),
+	}
+
+	if requestType != events.RequestCreate {
+		req.PhysicalResourceID = "testkit-physical-id"
+	}
+	if requestType == events.RequestUpdate {
+		req.OldResourceProperties = k.marshal(oldProperties)
+	}
+	return req
+}
+
+// Run takes handler and req and runs the handler with the same timeout and lambda context as the lambda function.
+// The test fails if handler didn't send exactly one response to CloudFormation.
+// Returns *Response.
+func (k *Kit) Run(handler func(ctx context.Context, req *events.Request) error, req *events.Request) *Response {
+	k.t.Helper()
+
+	k.mu.Lock()
+	sent := len(k.responses)
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	handler(lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{AwsRequestID: LambdaRequestID}), req)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.responses) != sent+1 {
+		k.t.Fatalf("Expected 1 response to CloudFormation but got %d", len(k.responses)-sent)
+	}
+	return k.responses[len(k.responses)-1]
+}
+
+// Calls returns the operations that were called in order.
+// Returns []string.
+func (k *Kit) Calls() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	l := []string{}
+	for _, call := range k.calls {
+		l = append(l, call.Operation)
+	}
+	return l
+}
+
+// Call returns the last call of operation. The test fails if operation wasn't called.
+// Returns *Call.
+func (k *Kit) Call(operation string) *Call {
+	k.t.Helper()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for i := len(k.calls) - 1; i >= 0; i-- {
+		if k.calls[i].Operation == operation {
+			return k.calls[i]
+		}
+	}
+
+	k.t.Fatalf("%s was never called", operation)
+	return nil
+}
+
+// CallsTo returns every call of operation in order.
+// Returns []*Call.
+func (k *Kit) CallsTo(operation string) []*Call {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	calls := []*Call{}
+	for _, call := range k.calls {
+		if call.Operation == operation {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Input unmarshals the JSON input of the last call of operation into v.
+// The test fails if operation wasn't called.
+func (k *Kit) Input(operation string, v interface{}) {
+	k.t.Helper()
+
+	call := k.Call(operation)
+	if err := json.Unmarshal(call.Body, v); err != nil {
+		k.t.Fatalf("Couldn't unmarshal input of %s. Error %s", operation, err.Error())
+	}
+}
+
+// marshal returns v as JSON.
+// Returns json.RawMessage.
+func (k *Kit) marshal(v interface{}) json.RawMessage {
+	k.t.Helper()
+
+	switch val := v.(type) {
+	case nil:
+		return nil
+
+	case string:
+		return json.RawMessage(val)
+
+	case []byte:
+		return json.RawMessage(val)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		k.t.Fatalf("Couldn't marshal properties. Error %s", err.Error())
+	}
+	return b
+}
+
+// serve handles the requests to the local server.
+func (k *Kit) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if r.URL.Path == responsePath {
+		resp := &Response{}
+		if err := json.Unmarshal(body, resp); err != nil {
+			k.t.Errorf("Couldn't unmarshal the response to CloudFormation. Error %s", err.Error())
+		}
+		k.responses = append(k.responses, resp)
+		return
+	}
+
+	// JSON APIs name the operation in X-Amz-Target, such as AWSCognitoIdentityProviderService.CreateUserPoolClient.
+	call, api := &Call{Body: body}, apiQuery
+	switch {
+	case r.Header.Get("X-Amz-Target") != "":
+		target := r.Header.Get("X-Amz-Target")
+		call.Operation, api = target[strings.LastIndex(target, ".")+1:], apiJSON
+
+	case restOperationName(r) != "":
+		call.Operation, call.Params, api = restOperationName(r), r.URL.Query(), apiREST
+
+	default:
+		call.Params, _ = url.ParseQuery(string(body))
+		call.Operation = call.Params.Get("Action")
+	}
+	k.calls = append(k.calls, call)
+
+	m, ok := k.mocks[call.Operation]
+	switch {
+	case !ok || m == nil:
+		k.t.Errorf("Unexpected call to %s, mock it with Respond or Fail", call.Operation)
+		m = &mock{status: http.StatusBadRequest, body: "UnknownOperationException:Not mocked by testkit"}
+
+	case m.next != nil:
+		k.mocks[call.Operation] = m.next
+	}
+
+	writeMock(w, call.Operation, api, m)
+}
+
+// restOperationName returns the name of the REST-XML operation that r calls, empty if r
+// doesn't call one.
+// Returns string.
+func restOperationName(r *http.Request) string {
+	for _, op := range restOperations {
+		if r.Method == op.method && op.path.MatchString(r.URL.Path) {
+			return op.name
+		}
+	}
+	return ""
+}
+
+// writeMock writes m as the response of operation in the format of api.
+func writeMock(w http.ResponseWriter, operation string, api string, m *mock) {
+	code, message := "", ""
+	if m.status != http.StatusOK {
+		parts := strings.SplitN(m.body, ":", 2)
+		code, message = parts[0], parts[1]
+	}
+
+	if api == apiJSON {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Header().Set("X-Amzn-Requestid", "testkit")
+		w.WriteHeader(m.status)
+
+		switch {
+		case m.status != http.StatusOK:
+			json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message})
+
+		case m.body == "":
+			fmt.Fprint(w, "{}")
+
+		default:
+			fmt.Fprint(w, m.body)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(m.status)
+
+	if m.status != http.StatusOK {
+		fmt.Fprintf(w, "<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>testkit</RequestId></ErrorResponse>", code, html.EscapeString(message))
+		return
+	}
+	if api == apiREST {
+		fmt.Fprintf(w, "<%[1]sResponse>%[2]s</%[1]sResponse>", operation, m.body)
+		return
+	}
+	fmt.Fprintf(w, "<%[1]sResponse><%[1]sResult>%[2]s</%[1]sResult><ResponseMetadata><RequestId>testkit</RequestId></ResponseMetadata></%[1]sResponse>", operation, m.body)
+}
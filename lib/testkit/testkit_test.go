@@ -0,0 +1,171 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/dwtechnologies/custom-cf/lib/awsclient"
+	"github.com/dwtechnologies/custom-cf/lib/awsconfig"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+)
+
+// handler is a custom resource that describes a client and tags a role.
+func handler(ctx context.Context, req *events.Request) error {
+	req.Lambda = events.LambdaFromContext(ctx)
+	if err := req.CheckResponseURL(); err != nil {
+		return err
+	}
+	if err := req.Validate(); err != nil {
+		return req.Send("", nil, err)
+	}
+
+	clients, err := awsclient.New(ctx, req, "Custom::Test")
+	if err != nil {
+		return req.Send("", nil, err)
+	}
+
+	props, old := &struct{ ClientId, RoleName string }{}, &struct{ ClientId, RoleName string }{}
+	if err := req.Unmarshal(props, old); err != nil {
+		return req.Send("", nil, err)
+	}
+
+	resp, err := clients.CognitoIdentityProvider().DescribeUserPoolClientRequest(&cognitoidentityprovider.DescribeUserPoolClientInput{
+		ClientId:   &props.ClientId,
+		UserPoolId: &props.ClientId,
+	}).Send()
+	if err != nil {
+		return req.Send("", nil, fmt.Errorf("Failed to describe Client. Error %w", err))
+	}
+
+	if _, err := clients.IAM().TagRoleRequest(&iam.TagRoleInput{RoleName: &props.RoleName, Tags: []iam.Tag{}}).Send(); err != nil {
+		return req.Send("", nil, fmt.Errorf("Failed to tag role. Error %w", err))
+	}
+
+	return req.Send(props.ClientId, map[string]string{"ClientName": *resp.UserPoolClient.ClientName}, nil)
+}
+
+// Test that both JSON and query APIs are mocked and that the response is recorded.
+func TestRun(t *testing.T) {
+	kit := New(t)
+	kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientName":"test"}}`)
+	kit.Respond("TagRole", "")
+
+	resp := kit.Run(handler, kit.Request(events.RequestCreate, "Custom::Test", map[string]string{"ClientId": "abc", "RoleName": "role"}, nil))
+
+	switch {
+	case resp.Status != "SUCCESS":
+		t.Errorf("Expected SUCCESS but got %s. Reason %s", resp.Status, resp.Reason)
+
+	case resp.PhysicalResourceID != "abc" || resp.Data["ClientName"] != "test":
+		t.Errorf("Unexpected response %+v", resp)
+
+	case fmt.Sprint(kit.Calls()) != "[DescribeUserPoolClient TagRole]":
+		t.Errorf("Unexpected calls %v", kit.Calls())
+
+	case kit.Call("TagRole").Params.Get("RoleName") != "role":
+		t.Errorf("Expected RoleName role but got %s", kit.Call("TagRole").Params.Get("RoleName"))
+	}
+
+	input := &cognitoidentityprovider.DescribeUserPoolClientInput{}
+	kit.Input("DescribeUserPoolClient", input)
+	if input.ClientId == nil || *input.ClientId != "abc" {
+		t.Errorf("Expected ClientId abc in input")
+	}
+}
+
+// Test that mocked errors are returned as AWS errors for both JSON and query APIs.
+func TestFail(t *testing.T) {
+	tests := []struct {
+		operation string
+		code      string
+		reason    string
+	}{
+		{operation: "DescribeUserPoolClient", code: "ResourceNotFoundException", reason: "Failed to describe Client. Error ResourceNotFoundException: Client doesn't exist"},
+		{operation: "TagRole", code: "NoSuchEntity", reason: "Failed to tag role. Error NoSuchEntity: Client doesn't exist"},
+	}
+
+	for i, test := range tests {
+		kit := New(t)
+		kit.Respond("DescribeUserPoolClient", `{"UserPoolClient":{"ClientName":"test"}}`)
+		kit.Fail(test.operation, test.code, "Client doesn't exist")
+
+		resp := kit.Run(handler, kit.Request(events.RequestUpdate, "Custom::Test", `{"ClientId":"abc","RoleName":"role"}`, `{}`))
+		switch {
+		case resp.Status != "FAILED" || !strings.HasPrefix(resp.Reason, test.reason):
+			t.Errorf("Test number: %d failed. Expected FAILED with reason %q but got %s %q", i+1, test.reason, resp.Status, resp.Reason)
+
+		case !strings.HasSuffix(resp.Reason, "[RequestId: "+LambdaRequestID+", Logs: https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logEventViewer:group=/aws/lambda/testkit;stream=2019/01/22/[$LATEST]testkit]"):
+			t.Errorf("Test number: %d failed. Expected the request ID and logs in reason %q", i+1, resp.Reason)
+		}
+	}
+}
+
+// Test that RespondInOrder answers the calls in order and repeats the last response.
+func TestRespondInOrder(t *testing.T) {
+	kit := New(t)
+	kit.RespondInOrder("DescribeUserPoolClient", `{"UserPoolClient":{"ClientName":"first"}}`, `{"UserPoolClient":{"ClientName":"second"}}`)
+	kit.Respond("TagRole", "")
+
+	for i, want := range []string{"first", "second", "second"} {
+		resp := kit.Run(handler, kit.Request(events.RequestCreate, "Custom::Test", map[string]string{"ClientId": "abc", "RoleName": "role"}, nil))
+		if resp.Data["ClientName"] != want {
+			t.Errorf("Test number: %d failed. Wanted ClientName %s but got %s", i+1, want, resp.Data["ClientName"])
+		}
+	}
+}
+
+// Test that the REST-XML API of Route 53 is mocked by the method and path of the operation.
+func TestREST(t *testing.T) {
+	kit := New(t)
+	kit.Respond("ChangeResourceRecordSets", "<ChangeInfo><Id>/change/C123</Id><Status>PENDING</Status><SubmittedAt>2019-01-22T00:00:00Z</SubmittedAt></ChangeInfo>")
+	kit.Respond("GetChange", "<ChangeInfo><Id>/change/C123</Id><Status>INSYNC</Status><SubmittedAt>2019-01-22T00:00:00Z</SubmittedAt></ChangeInfo>")
+	kit.Fail("ListResourceRecordSets", "NoSuchHostedZone", "No hosted zone found with ID: Z123")
+
+	cfg, err := awsconfig.LoadDefault()
+	if err != nil {
+		t.Fatalf("Couldn't load AWS config. Error %s", err.Error())
+	}
+	dns := route53.New(cfg)
+
+	change, err := dns.ChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String("Z123"),
+		ChangeBatch: &route53.ChangeBatch{Changes: []route53.Change{{
+			Action:            route53.ChangeActionUpsert,
+			ResourceRecordSet: &route53.ResourceRecordSet{Name: aws.String("auth.example.com"), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("192.0.2.1")}}},
+		}}},
+	}).Send()
+	switch {
+	case err != nil:
+		t.Fatalf("Unexpected error %s", err.Error())
+
+	case aws.StringValue(change.ChangeInfo.Id) != "/change/C123" || change.ChangeInfo.Status != route53.ChangeStatusPending:
+		t.Errorf("Unexpected ChangeInfo %+v", change.ChangeInfo)
+
+	case !strings.Contains(string(kit.Call("ChangeResourceRecordSets").Body), "<Name>auth.example.com</Name>"):
+		t.Errorf("Expected the record in the XML input %s", kit.Call("ChangeResourceRecordSets").Body)
+	}
+
+	got, err := dns.GetChangeRequest(&route53.GetChangeInput{Id: change.ChangeInfo.Id}).Send()
+	if err != nil || got.ChangeInfo.Status != route53.ChangeStatusInsync {
+		t.Errorf("Expected the change to be INSYNC, got %+v and error %v", got, err)
+	}
+
+	_, err = dns.ListResourceRecordSetsRequest(&route53.ListResourceRecordSetsInput{HostedZoneId: aws.String("Z123"), StartRecordName: aws.String("auth.example.com")}).Send()
+	if err == nil || !strings.HasPrefix(err.Error(), "NoSuchHostedZone: No hosted zone found with ID: Z123") {
+		t.Errorf("Expected a NoSuchHostedZone error but got %v", err)
+	}
+
+	switch {
+	case fmt.Sprint(kit.Calls()) != "[ChangeResourceRecordSets GetChange ListResourceRecordSets]":
+		t.Errorf("Unexpected calls %v", kit.Calls())
+
+	case kit.Call("ListResourceRecordSets").Params.Get("name") != "auth.example.com":
+		t.Errorf("Expected name auth.example.com in the query but got %v", kit.Call("ListResourceRecordSets").Params)
+	}
+}
@@ -0,0 +1,119 @@
+// Package registry contains every custom resource in this repository keyed by
+// its ResourceType. It is used by the combined provider lambda to dispatch
+// requests and by the tools in cmd/ to find the implementation of a resource
+// used in a template.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	identitypoolroles "github.com/dwtechnologies/custom-cf/cognito/identitypool-roles"
+	userpoolclient "github.com/dwtechnologies/custom-cf/cognito/userpool-client"
+	userpooldomain "github.com/dwtechnologies/custom-cf/cognito/userpool-domain"
+	userpoolfederation "github.com/dwtechnologies/custom-cf/cognito/userpool-federation"
+	userpoolmfa "github.com/dwtechnologies/custom-cf/cognito/userpool-mfa"
+	userpooluicustomization "github.com/dwtechnologies/custom-cf/cognito/userpool-uicustomization"
+	roletags "github.com/dwtechnologies/custom-cf/iam/role-tags"
+	"github.com/dwtechnologies/custom-cf/lib/drift"
+	"github.com/dwtechnologies/custom-cf/lib/events"
+	"github.com/dwtechnologies/custom-cf/lib/spec"
+)
+
+// Resource describes a custom resource implemented in this repository.
+type Resource struct {
+	Type     string // The ResourceType used in templates, such as Custom::CognitoUserPoolClient.
+	Function string // The folder of the function, such as cognito/userpool-client.
+
+	// Handler handles the CloudFormation request for the resource, including
+	// sending the response to the pre-signed S3 url.
+	Handler func(ctx context.Context, req *events.Request) error
+
+	// Detect compares the declared properties with the live resource that has the physical ID
+	// physicalID, which is empty if it isn't known. Is nil if the resource doesn't support drift detection.
+	Detect func(ctx context.Context, cfg aws.Config, properties json.RawMessage, physicalID string) (*drift.Result, error)
+
+	// Validate validates properties in the same way as the lambda function does
+	// before calling AWS.
+	Validate func(properties json.RawMessage) error
+
+	// Spec describes the properties, attributes and IAM policy of the resource.
+	Spec *spec.Resource
+}
+
+var resources = map[string]*Resource{
+	identitypoolroles.ResourceType: {
+		Type:     identitypoolroles.ResourceType,
+		Function: "cognito/identitypool-roles",
+		Handler:  identitypoolroles.Handler,
+		Validate: identitypoolroles.Validate,
+		Spec:     identitypoolroles.Spec,
+	},
+	userpoolclient.ResourceType: {
+		Type:     userpoolclient.ResourceType,
+		Function: "cognito/userpool-client",
+		Handler:  userpoolclient.Handler,
+		Detect:   userpoolclient.Detect,
+		Validate: userpoolclient.Validate,
+		Spec:     userpoolclient.Spec,
+	},
+	userpooldomain.ResourceType: {
+		Type:     userpooldomain.ResourceType,
+		Function: "cognito/userpool-domain",
+		Handler:  userpooldomain.Handler,
+		Detect:   userpooldomain.Detect,
+		Validate: userpooldomain.Validate,
+		Spec:     userpooldomain.Spec,
+	},
+	userpoolfederation.ResourceType: {
+		Type:     userpoolfederation.ResourceType,
+		Function: "cognito/userpool-federation",
+		Handler:  userpoolfederation.Handler,
+		Detect:   userpoolfederation.Detect,
+		Validate: userpoolfederation.Validate,
+		Spec:     userpoolfederation.Spec,
+	},
+	userpoolmfa.ResourceType: {
+		Type:     userpoolmfa.ResourceType,
+		Function: "cognito/userpool-mfa",
+		Handler:  userpoolmfa.Handler,
+		Detect:   userpoolmfa.Detect,
+		Validate: userpoolmfa.Validate,
+		Spec:     userpoolmfa.Spec,
+	},
+	userpooluicustomization.ResourceType: {
+		Type:     userpooluicustomization.ResourceType,
+		Function: "cognito/userpool-uicustomization",
+		Handler:  userpooluicustomization.Handler,
+		Validate: userpooluicustomization.Validate,
+		Spec:     userpooluicustomization.Spec,
+	},
+	roletags.ResourceType: {
+		Type:     roletags.ResourceType,
+		Function: "iam/role-tags",
+		Handler:  roletags.Handler,
+		Validate: roletags.Validate,
+		Spec:     roletags.Spec,
+	},
+}
+
+// Get returns the Resource registered for resourceType.
+// Returns *Resource and bool.
+func Get(resourceType string) (*Resource, bool) {
+	res, ok := resources[resourceType]
+	return res, ok
+}
+
+// All returns all registered resources sorted by their ResourceType.
+// Returns []*Resource.
+func All() []*Resource {
+	all := make([]*Resource, 0, len(resources))
+	for _, res := range resources {
+		all = append(all, res)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Type < all[j].Type })
+	return all
+}